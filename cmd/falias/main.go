@@ -4,25 +4,54 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/oscar.rivas/falias/internal/config"
 	"github.com/oscar.rivas/falias/internal/export"
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/profile"
 	"github.com/oscar.rivas/falias/internal/scanner"
 	"github.com/oscar.rivas/falias/internal/ui"
 )
 
 var (
-	shellFlag      = flag.String("shell", "", "Force shell type (bash or zsh, auto-detect if not specified)")
+	shellFlag      = flag.String("shell", "", "Force shell type (bash, zsh or fish, auto-detect if not specified)")
 	rootFlag       = flag.String("root", "", "Override starting file (default: ~/.bashrc or ~/.zshrc)")
+	sessionFlag    = flag.String("session", "", "Session whose startup files are scanned (login, interactive or non-interactive)")
 	jsonFlag       = flag.Bool("json", false, "Export aliases as JSON and exit")
+	jsonAllFlag    = flag.Bool("json-all", false, "Export aliases, functions and dynamic includes as JSON and exit")
 	debugFlag      = flag.Bool("debug", false, "Show includes graph and unresolved paths")
 	helpFlag       = flag.Bool("help", false, "Show help")
 	versionFlag    = flag.Bool("version", false, "Show version")
 	themeFlag      = flag.String("theme", "", "Set the color theme and save to config")
 	listThemesFlag = flag.Bool("list-themes", false, "List available themes")
+	importEnvFlag  = flag.Bool("import-env", false, "Start scans with the variables of the current environment")
+	profileFlag    = flag.String("profile", "", "Evaluate conditions on a target: 'local' or a profile file")
+	varFlags       varList
 )
 
+func init() {
+	flag.Var(&varFlags, "var", "Set a variable for path resolution (NAME=value, repeatable)")
+}
+
+// varList collects repeated --var NAME=value flags
+type varList []string
+
+func (v *varList) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *varList) Set(value string) error {
+	name, _, ok := strings.Cut(value, "=")
+	if !ok || !config.IsVariableName(name) {
+		return fmt.Errorf("expected NAME=value, got %q", value)
+	}
+	*v = append(*v, value)
+	return nil
+}
+
 const version = "1.0.0"
 
 func main() {
@@ -70,17 +99,41 @@ func main() {
 	}
 
 	// Validate shell
-	if shell != "bash" && shell != "zsh" {
-		fmt.Fprintf(os.Stderr, "Error: Invalid shell '%s'. Must be 'bash' or 'zsh'.\n", shell)
+	if shell != "bash" && shell != "zsh" && shell != "fish" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid shell '%s'. Must be 'bash', 'zsh' or 'fish'.\n", shell)
 		os.Exit(1)
 	}
 
+	// Detect or use specified session type
+	session := scanner.DefaultSession(shell)
+	if *sessionFlag != "" {
+		var ok bool
+		if session, ok = scanner.ParseSession(*sessionFlag); !ok {
+			fmt.Fprintf(os.Stderr, "Error: Invalid session '%s'. Must be 'login', 'interactive' or 'non-interactive'.\n", *sessionFlag)
+			os.Exit(1)
+		}
+	}
+
 	// Get root files
+	s := scanner.NewScanner()
+	env, err := scanEnvironment(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	s.SetEnvironment(env)
+	if evaluator, err := conditionEvaluator(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	} else if evaluator != nil {
+		s.SetEvaluator(evaluator)
+	}
 	var rootFiles []string
 	if *rootFlag != "" {
 		rootFiles = []string{*rootFlag}
 	} else {
-		allRoots := scanner.GetDefaultRootFiles(shell)
+		s.SetSession(session)
+		allRoots := scanner.GetDefaultRootFiles(shell, session, env)
 		rootFiles = scanner.FilterExistingFiles(allRoots)
 
 		if len(rootFiles) == 0 {
@@ -91,24 +144,76 @@ func main() {
 	}
 
 	// JSON export mode
-	if *jsonFlag {
-		exportJSON(shell, rootFiles)
+	if *jsonFlag || *jsonAllFlag {
+		exportJSON(s, shell, rootFiles, *jsonAllFlag)
 		return
 	}
 
 	// Debug mode
 	if *debugFlag {
-		showDebug(shell, rootFiles)
+		showDebug(s, shell, rootFiles)
 		return
 	}
 
 	theme := config.GetTheme(cfg.Theme)
-	runTUI(shell, rootFiles, theme)
+	runTUI(s, shell, rootFiles, theme)
+}
+
+// scanEnvironment returns the variables scans start with: the process
+// environment when imported, then config vars, then --var flags, later
+// sources taking precedence
+func scanEnvironment(cfg *config.Config) (map[string]string, error) {
+	if err := cfg.CheckVars(); err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string)
+
+	if *importEnvFlag {
+		for _, entry := range os.Environ() {
+			if name, value, ok := strings.Cut(entry, "="); ok && config.IsVariableName(name) {
+				vars[name] = value
+			}
+		}
+	}
+
+	for name, value := range cfg.Vars {
+		vars[name] = value
+	}
+
+	for _, entry := range varFlags {
+		name, value, _ := strings.Cut(entry, "=")
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+// conditionEvaluator returns the evaluator of the target profile from
+// --profile or the config, or nil if conditions aren't evaluated
+func conditionEvaluator(cfg *config.Config) (*profile.Evaluator, error) {
+	target := cfg.Profile
+	if *profileFlag != "" {
+		target = *profileFlag
+	}
+
+	switch target {
+	case "":
+		return nil, nil
+	case "local":
+		return profile.NewEvaluator(profile.Local{}), nil
+	}
+
+	p, err := profile.Load(target)
+	if err != nil {
+		return nil, err
+	}
+	return profile.NewEvaluator(p), nil
 }
 
 // runTUI starts the Bubble Tea TUI
-func runTUI(shell string, rootFiles []string, theme config.Theme) {
-	m := ui.NewModel(shell, rootFiles, theme)
+func runTUI(s *scanner.Scanner, shell string, rootFiles []string, theme config.Theme) {
+	m := ui.NewModel(s, shell, rootFiles, theme)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
@@ -117,9 +222,8 @@ func runTUI(shell string, rootFiles []string, theme config.Theme) {
 	}
 }
 
-// exportJSON exports aliases as JSON
-func exportJSON(shell string, rootFiles []string) {
-	s := scanner.NewScanner()
+// exportJSON exports aliases as JSON, along with functions and dynamic includes if all is set
+func exportJSON(s *scanner.Scanner, shell string, rootFiles []string, all bool) {
 	result, err := s.ScanShellFiles(shell, rootFiles)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
@@ -127,15 +231,18 @@ func exportJSON(shell string, rootFiles []string) {
 	}
 
 	exporter := export.NewJSONExporter(true)
-	if err := exporter.ExportAliases(result, os.Stdout); err != nil {
+	write := exporter.ExportAliases
+	if all {
+		write = exporter.ExportAll
+	}
+	if err := write(result, os.Stdout); err != nil {
 		fmt.Fprintf(os.Stderr, "Error exporting: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 // showDebug shows debug information
-func showDebug(shell string, rootFiles []string) {
-	s := scanner.NewScanner()
+func showDebug(s *scanner.Scanner, shell string, rootFiles []string) {
 	result, err := s.ScanShellFiles(shell, rootFiles)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
@@ -150,6 +257,8 @@ func showDebug(shell string, rootFiles []string) {
 		status := "OK"
 		if !file.Exists {
 			status = "MISSING"
+		} else if file.Skipped {
+			status = "NOT READ"
 		} else if !file.Readable {
 			status = "PERMISSION DENIED"
 		}
@@ -157,16 +266,106 @@ func showDebug(shell string, rootFiles []string) {
 		if file.Conditional {
 			conditional = " [CONDITIONAL]"
 		}
-		fmt.Printf("  %s - %s%s\n", path, status, conditional)
+		if file.Excluded {
+			conditional += " [EXCLUDED]"
+		}
+		plugin := ""
+		if file.Plugin != "" {
+			plugin = fmt.Sprintf(" [PLUGIN %s %s]", file.Manager, file.Plugin)
+		}
+		fmt.Printf("  %s - %s%s%s\n", path, status, conditional, plugin)
+		if len(file.Conditions) > 0 {
+			fmt.Printf("    Condition: %s\n", model.ConditionText(file.Conditions))
+		}
 		if len(file.Includes) > 0 {
 			fmt.Printf("    Includes: %v\n", file.Includes)
 		}
+		for _, dyn := range file.Dynamic {
+			fmt.Printf("    Dynamic: %s (line %s)\n", dyn.Command, dyn.Location.LineRange())
+			if len(dyn.Aliases) > 0 {
+				fmt.Printf("      Documented aliases: %s\n", strings.Join(dyn.Aliases, ", "))
+			} else if !dyn.Known {
+				fmt.Printf("      Unknown tool, may define aliases\n")
+			}
+		}
 		if len(file.Aliases) > 0 {
 			fmt.Printf("    Aliases: %d\n", len(file.Aliases))
 		}
+		if len(file.Functions) > 0 {
+			fmt.Printf("    Functions: %d\n", len(file.Functions))
+		}
 	}
 
 	fmt.Printf("\nAliases Found: %d\n", len(result.Aliases))
+	fmt.Printf("Functions Found: %d\n", len(result.Functions))
+
+	if len(result.Variables) > 0 {
+		fmt.Printf("\nVariables (%d, in scan order):\n", len(result.Variables))
+		for _, v := range result.Variables {
+			where := fmt.Sprintf("%s:%s", v.Location.FilePath, v.Location.LineRange())
+			if v.Function != "" {
+				where += fmt.Sprintf(", local to %s", v.Function)
+			}
+			switch {
+			case v.Unset:
+				fmt.Printf("  unset %s (%s)\n", v.Name, where)
+			case !v.Resolved:
+				fmt.Printf("  %s unknown, cannot evaluate %q (%s)\n", v.Name, v.RawValue, where)
+			default:
+				fmt.Printf("  %s=%s (%s)\n", v.Name, v.Value, where)
+			}
+		}
+	}
+
+	fmt.Printf("\nSearch Path (%d):\n", len(result.SearchPath))
+	for _, dir := range result.SearchPath {
+		fmt.Printf("  %s\n", dir)
+	}
+
+	var shadows []string
+	for _, entry := range result.GetAliasesSorted() {
+		for _, shadow := range entry.Shadows {
+			hidden := string(shadow.Kind)
+			if shadow.Path != "" {
+				hidden += " " + shadow.Path
+			} else if shadow.Location != nil {
+				hidden += fmt.Sprintf(" at %s:%s", shadow.Location.FilePath, shadow.Location.LineRange())
+			}
+			shadows = append(shadows, fmt.Sprintf("%s hides %s (%s)", entry.Name, hidden, shadow.Severity))
+		}
+	}
+	if len(shadows) > 0 {
+		sort.Strings(shadows)
+		fmt.Printf("\nShadowed Commands (%d):\n", len(shadows))
+		for _, shadow := range shadows {
+			fmt.Printf("  %s\n", shadow)
+		}
+	}
+
+	if len(result.Cycles) > 0 {
+		fmt.Printf("\nAlias Cycles (%d):\n", len(result.Cycles))
+		for _, cycle := range result.Cycles {
+			fmt.Printf("  %s\n", cycle.Path())
+			for _, member := range cycle.Members {
+				fmt.Printf("    %s (%s:%s)\n", member.Name, member.Location.FilePath, member.Location.LineRange())
+			}
+		}
+	}
+
+	var dead []string
+	for _, entry := range result.GetAliasesSorted() {
+		if entry.MissingCommand != "" {
+			loc := entry.ActiveLocation
+			dead = append(dead, fmt.Sprintf("%s: %s not found (%s:%s)", entry.Name, entry.MissingCommand, loc.FilePath, loc.LineRange()))
+		}
+	}
+	if len(dead) > 0 {
+		sort.Strings(dead)
+		fmt.Printf("\nDead Aliases (%d):\n", len(dead))
+		for _, line := range dead {
+			fmt.Printf("  %s\n", line)
+		}
+	}
 
 	if len(result.UnresolvedPaths) > 0 {
 		fmt.Printf("\nUnresolved Paths (%d):\n", len(result.UnresolvedPaths))
@@ -216,9 +415,18 @@ USAGE:
   falias [flags]
 
 FLAGS:
-  --shell bash|zsh    Force shell type (auto-detect if not specified)
+  --shell bash|zsh|fish
+                      Force shell type (auto-detect if not specified)
   --root <path>       Override starting file (default: ~/.bashrc or ~/.zshrc)
+  --session <type>    Startup files to scan: login, interactive or
+                      non-interactive (default: login for zsh, else interactive);
+                      --debug lists the startup files it never reads
+  --var NAME=value    Set a variable for path resolution (repeatable)
+  --import-env        Start scans with the variables of the current environment
+  --profile <target>  Evaluate conditions on a target: 'local' or a profile
+                      file; definitions whose conditions are false are excluded
   --json              Export aliases as JSON and exit
+  --json-all          Export aliases, functions and dynamic includes as JSON
   --debug             Show includes graph and unresolved paths
   --theme <name>      Set color theme (use --list-themes to see options)
   --list-themes       List available color themes
@@ -232,7 +440,7 @@ KEYBOARD SHORTCUTS (in TUI):
   c                   Copy alias value to clipboard
   n                   Copy alias name to clipboard
   p                   Copy full alias definition
-  t                   Toggle view mode (All/By File/Overridden/Globals)
+  t                   Toggle view mode (All/By File/Overridden/Globals/Suffixes/Removed/Dead/Functions/Dynamic)
   r                   Rescan configuration files
   h or ?              Show help
   q or Ctrl+C         Quit
@@ -241,8 +449,14 @@ KEYBOARD SHORTCUTS (in TUI):
 EXAMPLES:
   falias                      # Auto-detect shell and scan
   falias --shell zsh          # Force zsh
+  falias --shell fish         # Scan config.fish, conf.d and functions
   falias --root ~/.zshrc      # Use specific file
+  falias --session interactive  # Skip .zprofile and .zlogin
+  falias --shell bash --session login  # /etc/profile and ~/.bash_profile
   falias --json               # Export as JSON
+  falias --json-all           # Also export functions and dynamic includes
+  falias --var ZSH=~/.oh-my-zsh --var DOTFILES=~/dotfiles
+  falias --profile linux.yaml # Aliases a Linux machine would get
   falias --json | jq '.'      # Pretty-print JSON
   falias --debug              # Show debug info
   falias --list-themes        # List available themes
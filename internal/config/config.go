@@ -4,13 +4,18 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Theme string `yaml:"theme"`
+	Theme string            `yaml:"theme"`
+	Vars  map[string]string `yaml:"vars,omitempty"` // Variables every scan starts with, e.g. ZSH or DOTFILES
+
+	// Target conditions are evaluated on: "local" or the path of a profile file
+	Profile string `yaml:"profile,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
@@ -75,6 +80,34 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// CheckVars returns an error naming the first entry of vars that isn't a
+// valid shell variable name, since a reference could never match it
+func (c *Config) CheckVars() error {
+	names := make([]string, 0, len(c.Vars))
+	for name := range c.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !IsVariableName(name) {
+			return fmt.Errorf("invalid variable name in config vars: %q", name)
+		}
+	}
+	return nil
+}
+
+// IsVariableName reports whether name is a valid shell variable name
+func IsVariableName(name string) bool {
+	for i, ch := range name {
+		isLetter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		if !isLetter && (i == 0 || ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
 // Save saves the configuration to disk
 func (c *Config) Save() error {
 	if err := EnsureConfigDir(); err != nil {
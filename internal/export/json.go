@@ -29,19 +29,78 @@ func (e *JSONExporter) Export(result *model.ScanResult, w io.Writer) error {
 	return encoder.Encode(result)
 }
 
+// simpleAlias is an alias in the simplified export format
+type simpleAlias struct {
+	Name      string              `json:"name"`
+	Value     string              `json:"value"`
+	Type      string              `json:"type"`
+	File      string              `json:"file"`
+	Line      int                 `json:"line"`
+	EndLine   int                 `json:"end_line"`
+	Override  bool                `json:"overridden,omitempty"`
+	Removed   bool                `json:"removed,omitempty"`
+	Plugin    string              `json:"plugin,omitempty"`
+	Condition string              `json:"condition,omitempty"`
+	Excluded  bool                `json:"excluded,omitempty"`
+	Shadows   []model.Shadow      `json:"shadows,omitempty"`
+	Expanded  string              `json:"expanded,omitempty"` // Only when other aliases change the value
+	Self      bool                `json:"self_reference,omitempty"`
+	Cycle     []model.CycleMember `json:"cycle,omitempty"`
+	Missing   string              `json:"missing_command,omitempty"` // Command that can't be found
+}
+
+// simpleFunction is a shell function in the simplified export format
+type simpleFunction struct {
+	Name     string `json:"name"`
+	Body     string `json:"body"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	EndLine  int    `json:"end_line"`
+	Override bool   `json:"overridden,omitempty"`
+}
+
+// simpleDynamic is a dynamic include in the simplified export format
+type simpleDynamic struct {
+	Tool    string   `json:"tool"`
+	Command string   `json:"command"`
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	EndLine int      `json:"end_line"`
+	Known   bool     `json:"known"`
+	Aliases []string `json:"aliases,omitempty"` // Aliases the tool is documented to define
+}
+
 // ExportAliases exports only the aliases in a simplified format
 func (e *JSONExporter) ExportAliases(result *model.ScanResult, w io.Writer) error {
-	// Create a simplified structure for output
-	type SimpleAlias struct {
-		Name     string `json:"name"`
-		Value    string `json:"value"`
-		Type     string `json:"type"`
-		File     string `json:"file"`
-		Line     int    `json:"line"`
-		Override bool   `json:"overridden,omitempty"`
+	return e.encode(simpleAliases(result), w)
+}
+
+// ExportAll exports aliases, shell functions and dynamic includes in a
+// simplified format, each list under its own key
+func (e *JSONExporter) ExportAll(result *model.ScanResult, w io.Writer) error {
+	return e.encode(struct {
+		Aliases   []simpleAlias    `json:"aliases"`
+		Functions []simpleFunction `json:"functions"`
+		Dynamic   []simpleDynamic  `json:"dynamic_includes"`
+	}{
+		Aliases:   simpleAliases(result),
+		Functions: simpleFunctions(result),
+		Dynamic:   simpleDynamics(result),
+	}, w)
+}
+
+// encode writes a value as JSON
+func (e *JSONExporter) encode(v interface{}, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	if e.pretty {
+		encoder.SetIndent("", "  ")
 	}
+	return encoder.Encode(v)
+}
 
-	aliases := make([]SimpleAlias, 0, len(result.Aliases))
+// simpleAliases converts the aliases of a scan result, sorted by name
+func simpleAliases(result *model.ScanResult) []simpleAlias {
+	aliases := make([]simpleAlias, 0, len(result.Aliases))
 
 	entries := result.GetAliasesSorted()
 
@@ -52,19 +111,75 @@ func (e *JSONExporter) ExportAliases(result *model.ScanResult, w io.Writer) erro
 
 	// Convert to simple format
 	for _, entry := range entries {
-		aliases = append(aliases, SimpleAlias{
+		aliases = append(aliases, simpleAlias{
+			Name:      entry.Name,
+			Value:     entry.ActiveValue,
+			Type:      string(entry.Type),
+			File:      entry.ActiveLocation.FilePath,
+			Line:      entry.ActiveLocation.LineNum,
+			EndLine:   entry.ActiveLocation.EndLine,
+			Override:  entry.IsOverridden,
+			Removed:   entry.IsRemoved,
+			Plugin:    entry.Plugin,
+			Condition: model.ConditionText(entry.Conditions),
+			Excluded:  entry.IsExcluded,
+			Shadows:   entry.Shadows,
+			Expanded:  expanded(entry),
+			Self:      entry.SelfReference,
+			Cycle:     cycle(result, entry),
+			Missing:   entry.MissingCommand,
+		})
+	}
+
+	return aliases
+}
+
+// simpleFunctions converts the shell functions of a scan result, sorted by name
+func simpleFunctions(result *model.ScanResult) []simpleFunction {
+	functions := make([]simpleFunction, 0, len(result.Functions))
+	for _, entry := range result.GetFunctionsSorted() {
+		functions = append(functions, simpleFunction{
 			Name:     entry.Name,
-			Value:    entry.ActiveValue,
-			Type:     string(entry.Type),
+			Body:     entry.ActiveBody,
 			File:     entry.ActiveLocation.FilePath,
 			Line:     entry.ActiveLocation.LineNum,
+			EndLine:  entry.ActiveLocation.EndLine,
 			Override: entry.IsOverridden,
 		})
 	}
+	return functions
+}
 
-	encoder := json.NewEncoder(w)
-	if e.pretty {
-		encoder.SetIndent("", "  ")
+// simpleDynamics converts the dynamic includes of a scan result, sorted by file and line
+func simpleDynamics(result *model.ScanResult) []simpleDynamic {
+	includes := make([]simpleDynamic, 0)
+	for _, dyn := range result.GetDynamicIncludes() {
+		includes = append(includes, simpleDynamic{
+			Tool:    dyn.Tool,
+			Command: dyn.Command,
+			File:    dyn.Location.FilePath,
+			Line:    dyn.Location.LineNum,
+			EndLine: dyn.Location.EndLine,
+			Known:   dyn.Known,
+			Aliases: dyn.Aliases,
+		})
+	}
+	return includes
+}
+
+// expanded returns the fully expanded command of an alias if expanding the
+// aliases it uses changes its value
+func expanded(entry *model.AliasEntry) string {
+	if len(entry.Expansion) < 2 {
+		return ""
+	}
+	return entry.Expanded
+}
+
+// cycle returns the members of the cycle an alias belongs to
+func cycle(result *model.ScanResult, entry *model.AliasEntry) []model.CycleMember {
+	if c := result.CycleOf(entry.Name); entry.InCycle && c != nil {
+		return c.Members
 	}
-	return encoder.Encode(aliases)
+	return nil
 }
@@ -1,26 +1,105 @@
 package model
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // AliasType represents the type of shell alias
 type AliasType string
 
 const (
 	AliasTypeNormal AliasType = "normal"
 	AliasTypeGlobal AliasType = "global"
+	AliasTypeSuffix AliasType = "suffix" // zsh alias -s, keyed by file extension
+
+	AliasTypeAbbreviation AliasType = "abbreviation" // fish abbr, expanded as you type
 )
 
+// Namespace returns the key prefix of alias types that live in their own namespace
+// Suffix aliases are separate from regular ones in zsh, so `alias -s md=glow`
+// and `alias md=mdless` are distinct aliases; the same holds for fish abbreviations
+func (t AliasType) Namespace() string {
+	switch t {
+	case AliasTypeSuffix:
+		return "*."
+	case AliasTypeAbbreviation:
+		return "abbr:"
+	default:
+		return ""
+	}
+}
+
+// AliasKey returns the key of an alias in ScanResult.Aliases
+func AliasKey(name string, aliasType AliasType) string {
+	return aliasType.Namespace() + name
+}
+
 // SourceLocation represents where an alias or include was defined
 type SourceLocation struct {
 	FilePath string `json:"file_path"`
-	LineNum  int    `json:"line_num"`
+	LineNum  int    `json:"line_num"` // First line of the statement
+	EndLine  int    `json:"end_line"` // Last line, differs from LineNum for multi-line statements
 	RawLine  string `json:"raw_line"`
 }
 
+// LineRange formats the line span as "12" or "12-14"
+func (l SourceLocation) LineRange() string {
+	if l.EndLine > l.LineNum {
+		return fmt.Sprintf("%d-%d", l.LineNum, l.EndLine)
+	}
+	return fmt.Sprintf("%d", l.LineNum)
+}
+
+// Condition is a test a statement runs under, written as in the source:
+// [[ $OSTYPE == darwin* ]], command -v eza >/dev/null, case $TERM in xterm*
+// A statement in a function body runs under a condition naming the function,
+// since it only runs when the function is called.
+type Condition struct {
+	Text     string `json:"text"`
+	Negated  bool   `json:"negated,omitempty"`  // Holds when the test fails: else branches and ||
+	Function bool   `json:"function,omitempty"` // Holds when the function named Text runs
+}
+
+// String formats the condition as a shell test
+func (c Condition) String() string {
+	if c.Function {
+		return c.Text + " runs"
+	}
+	if !c.Negated {
+		return c.Text
+	}
+	if strings.Contains(c.Text, "&&") || strings.Contains(c.Text, "||") {
+		return "! (" + c.Text + ")"
+	}
+	return "! " + c.Text
+}
+
+// ConditionText formats conditions that must all hold, empty if there are none
+func ConditionText(conditions []Condition) string {
+	parts := make([]string, len(conditions))
+	for i, c := range conditions {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, " && ")
+}
+
 // AliasDefinition represents a single definition of an alias
+// An unalias statement is recorded as a definition with Removed set
 type AliasDefinition struct {
 	Name     string         `json:"name"`
-	Value    string         `json:"value"`
+	Value    string         `json:"value"`     // Value as the shell stores it, after quote removal
+	RawValue string         `json:"raw_value"` // Value exactly as written in the source
 	Type     AliasType      `json:"type"`
 	Location SourceLocation `json:"location"`
+	Removed  bool           `json:"removed,omitempty"` // Removal event from unalias
+	Plugin   string         `json:"plugin,omitempty"`  // Framework plugin or theme that defined it
+
+	// Conditions the definition runs under, from the enclosing if, case and
+	// && blocks and the conditions its file was sourced under
+	Conditions []Condition `json:"conditions,omitempty"`
+	Excluded   bool        `json:"excluded,omitempty"` // Conditions don't hold on the target profile
 }
 
 // AliasEntry represents an alias with all its definitions
@@ -29,48 +108,228 @@ type AliasEntry struct {
 	Type           AliasType         `json:"type"`
 	ActiveValue    string            `json:"active_value"`
 	ActiveLocation SourceLocation    `json:"active_location"`
-	Definitions    []AliasDefinition `json:"definitions"` // All definitions in parse order
+	Definitions    []AliasDefinition `json:"definitions"` // All definitions and removals in parse order
 	IsOverridden   bool              `json:"is_overridden"`
+	IsRemoved      bool              `json:"is_removed"`                // Last event was an unalias, so the alias is inactive
+	Plugin         string            `json:"plugin,omitempty"`          // Plugin of the active definition
+	Conditions     []Condition       `json:"conditions,omitempty"`      // Conditions of the active definition
+	IsExcluded     bool              `json:"is_excluded,omitempty"`     // No definition applies on the target profile
+	Shadows        []Shadow          `json:"shadows,omitempty"`         // Commands the alias hides
+	Expansion      []ExpansionStep   `json:"expansion,omitempty"`       // Substitutions the shell makes running the alias
+	Expanded       string            `json:"expanded,omitempty"`        // Command line after all substitutions
+	SelfReference  bool              `json:"self_reference,omitempty"`  // Value uses the alias itself: alias ls='ls -G'
+	InCycle        bool              `json:"in_cycle,omitempty"`        // Member of a loop of aliases, see AliasCycle
+	MissingCommand string            `json:"missing_command,omitempty"` // Command of the expansion that can't be found
+}
+
+// ExpansionStep is one alias substitution when the shell expands a command line
+type ExpansionStep struct {
+	Alias string `json:"alias"` // Alias substituted
+	Text  string `json:"text"`  // Command line after the substitution
+}
+
+// ShadowSeverity returns the highest severity of the commands the alias hides,
+// empty if it hides none
+func (e *AliasEntry) ShadowSeverity() Severity {
+	var highest Severity
+	for _, shadow := range e.Shadows {
+		if shadow.Severity.rank() > highest.rank() {
+			highest = shadow.Severity
+		}
+	}
+	return highest
+}
+
+// ShadowKind is the kind of command an alias hides
+type ShadowKind string
+
+const (
+	ShadowKeyword    ShadowKind = "keyword"    // Reserved word of the shell
+	ShadowBuiltin    ShadowKind = "builtin"    // Command built into the shell
+	ShadowFunction   ShadowKind = "function"   // Function defined in the scanned files
+	ShadowExecutable ShadowKind = "executable" // Program on PATH
+)
+
+// Severity rates how likely hiding a command breaks the shell or scripts
+type Severity string
+
+const (
+	SeverityLow    Severity = "low"    // Commands mostly typed by hand, or programs on PATH
+	SeverityMedium Severity = "medium" // Builtins and functions scripts may call
+	SeverityHigh   Severity = "high"   // Reserved words and builtins that change shell state or control flow
+)
+
+// rank orders severities, an empty severity lowest
+func (s Severity) rank() int {
+	switch s {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Shadow is a command an alias hides, since the shell expands the alias first
+type Shadow struct {
+	Kind     ShadowKind      `json:"kind"`
+	Severity Severity        `json:"severity"`
+	Path     string          `json:"path,omitempty"`     // Executable that's hidden
+	Location *SourceLocation `json:"location,omitempty"` // Definition of the function that's hidden
+}
+
+// AliasCycle is a loop of aliases whose values use each other, such as
+// alias a=b and alias b=a. The shell doesn't expand an alias inside its own
+// expansion, so running a member ends up running a command named after one
+// of them, which usually doesn't exist. An alias using itself is harmless
+// and not a cycle.
+type AliasCycle struct {
+	Members []CycleMember `json:"members"` // In reference order, starting with the first name
+}
+
+// CycleMember is an alias of a cycle and where its active definition is
+type CycleMember struct {
+	Name     string         `json:"name"`
+	Location SourceLocation `json:"location"`
+}
+
+// Path formats the cycle as a chain of references: a → b → a
+func (c AliasCycle) Path() string {
+	names := make([]string, 0, len(c.Members)+1)
+	for _, member := range c.Members {
+		names = append(names, member.Name)
+	}
+	if len(c.Members) > 0 {
+		names = append(names, c.Members[0].Name)
+	}
+	return strings.Join(names, " → ")
 }
 
-// AddDefinition adds a new definition to the alias entry
+// AddDefinition adds a new definition or removal event to the alias entry
+// A removal keeps the last value for reference but marks the entry inactive
+// Excluded events are kept in the history but never change the active definition
 func (e *AliasEntry) AddDefinition(def AliasDefinition) {
 	e.Definitions = append(e.Definitions, def)
+
+	if def.Excluded {
+		return
+	}
+
+	if def.Removed {
+		e.IsRemoved = true
+		return
+	}
+
+	e.IsRemoved = false
+	e.IsExcluded = false
 	e.ActiveValue = def.Value
 	e.ActiveLocation = def.Location
 	e.Type = def.Type
+	e.Plugin = def.Plugin
+	e.Conditions = def.Conditions
+
+	defined := 0
+	for _, d := range e.Definitions {
+		if !d.Removed && !d.Excluded {
+			defined++
+		}
+	}
+	if defined > 1 {
+		e.IsOverridden = true
+	}
+}
+
+// FunctionDefinition represents a single definition of a shell function
+type FunctionDefinition struct {
+	Name     string         `json:"name"`
+	Body     string         `json:"body"` // Full source text of the definition
+	Location SourceLocation `json:"location"`
+}
+
+// FunctionEntry represents a shell function with all its definitions
+type FunctionEntry struct {
+	Name           string               `json:"name"`
+	ActiveBody     string               `json:"active_body"`
+	ActiveLocation SourceLocation       `json:"active_location"`
+	Definitions    []FunctionDefinition `json:"definitions"` // All definitions in parse order
+	IsOverridden   bool                 `json:"is_overridden"`
+}
+
+// AddDefinition adds a new definition to the function entry
+func (e *FunctionEntry) AddDefinition(def FunctionDefinition) {
+	e.Definitions = append(e.Definitions, def)
+	e.ActiveBody = def.Body
+	e.ActiveLocation = def.Location
 	if len(e.Definitions) > 1 {
 		e.IsOverridden = true
 	}
 }
 
+// VariableAssignment records where a variable used for path resolution was set
+type VariableAssignment struct {
+	Name     string         `json:"name"`
+	Value    string         `json:"value"`              // Value after expansion
+	RawValue string         `json:"raw_value"`          // Value as written, quotes removed
+	Resolved bool           `json:"resolved"`           // False if the value couldn't be evaluated
+	Unset    bool           `json:"unset,omitempty"`    // Removed by unset
+	Function string         `json:"function,omitempty"` // Function the variable is local to
+	Location SourceLocation `json:"location"`
+}
+
+// DynamicInclude is shell code a file generates by running a command, such as
+// eval "$(zoxide init zsh)". The command is recorded but never run.
+type DynamicInclude struct {
+	Command  string         `json:"command"`           // Command whose output is evaluated
+	Tool     string         `json:"tool"`              // Program the command runs
+	Known    bool           `json:"known"`             // Tool is in the catalog of known generators
+	Aliases  []string       `json:"aliases,omitempty"` // Aliases the tool is documented to define
+	Location SourceLocation `json:"location"`
+}
+
 // SourceFile represents a parsed shell configuration file
 type SourceFile struct {
-	Path        string            `json:"path"`
-	Exists      bool              `json:"exists"`
-	Readable    bool              `json:"readable"`
-	Conditional bool              `json:"conditional"` // Was it in a conditional include?
-	Aliases     []AliasDefinition `json:"aliases"`
-	Includes    []string          `json:"includes"` // Raw paths to sourced files
-	Error       string            `json:"error,omitempty"`
+	Path        string               `json:"path"`
+	Exists      bool                 `json:"exists"`
+	Readable    bool                 `json:"readable"`
+	Conditional bool                 `json:"conditional"`          // Was it in a conditional include?
+	Conditions  []Condition          `json:"conditions,omitempty"` // Conditions the file is sourced under
+	Excluded    bool                 `json:"excluded,omitempty"`   // Conditions don't hold on the target profile
+	Skipped     bool                 `json:"skipped,omitempty"`    // Startup file the scanned session never reads
+	Aliases     []AliasDefinition    `json:"aliases"`
+	Functions   []FunctionDefinition `json:"functions"`
+	Includes    []string             `json:"includes"`          // Raw paths to sourced files
+	Dynamic     []DynamicInclude     `json:"dynamic_includes"`  // Code generated by commands, never run
+	Manager     string               `json:"manager,omitempty"` // Plugin manager that loaded the file: oh-my-zsh, zinit, antidote...
+	Plugin      string               `json:"plugin,omitempty"`  // Plugin or theme the file belongs to, as declared to the manager
+	Error       string               `json:"error,omitempty"`
 }
 
 // ScanResult represents the complete result of scanning shell files
 type ScanResult struct {
-	Aliases         map[string]*AliasEntry `json:"aliases"`          // Key: alias name
-	Files           map[string]*SourceFile `json:"files"`            // Key: absolute path
-	UnresolvedPaths []string               `json:"unresolved_paths"` // Paths we couldn't resolve
-	Warnings        []string               `json:"warnings"`
-	Shell           string                 `json:"shell"`
-	RootFiles       []string               `json:"root_files"`
+	Aliases         map[string]*AliasEntry    `json:"aliases"`          // Key: AliasKey of the alias
+	Functions       map[string]*FunctionEntry `json:"functions"`        // Key: function name
+	Files           map[string]*SourceFile    `json:"files"`            // Key: absolute path
+	UnresolvedPaths []string                  `json:"unresolved_paths"` // Paths we couldn't resolve
+	Variables       []VariableAssignment      `json:"variables"`        // Variable assignments in scan order
+	SearchPath      []string                  `json:"search_path"`      // Directories of PATH as the scanned files leave it
+	Cycles          []AliasCycle              `json:"cycles"`           // Loops of aliases using each other
+	Warnings        []string                  `json:"warnings"`
+	Shell           string                    `json:"shell"`
+	RootFiles       []string                  `json:"root_files"`
 }
 
 // NewScanResult creates a new empty scan result
 func NewScanResult(shell string, rootFiles []string) *ScanResult {
 	return &ScanResult{
 		Aliases:         make(map[string]*AliasEntry),
+		Functions:       make(map[string]*FunctionEntry),
 		Files:           make(map[string]*SourceFile),
 		UnresolvedPaths: make([]string, 0),
+		Variables:       make([]VariableAssignment, 0),
+		Cycles:          make([]AliasCycle, 0),
 		Warnings:        make([]string, 0),
 		Shell:           shell,
 		RootFiles:       rootFiles,
@@ -79,16 +338,63 @@ func NewScanResult(shell string, rootFiles []string) *ScanResult {
 
 // AddAlias adds or updates an alias in the scan result
 func (r *ScanResult) AddAlias(def AliasDefinition) {
-	if entry, exists := r.Aliases[def.Name]; exists {
+	key := AliasKey(def.Name, def.Type)
+	if entry, exists := r.Aliases[key]; exists {
 		entry.AddDefinition(def)
 	} else {
-		r.Aliases[def.Name] = &AliasEntry{
+		r.Aliases[key] = &AliasEntry{
 			Name:           def.Name,
 			Type:           def.Type,
 			ActiveValue:    def.Value,
 			ActiveLocation: def.Location,
 			Definitions:    []AliasDefinition{def},
 			IsOverridden:   false,
+			Plugin:         def.Plugin,
+			Conditions:     def.Conditions,
+			IsExcluded:     def.Excluded,
+		}
+	}
+}
+
+// RemoveAlias records an unalias of the alias stored under key (see AliasKey)
+// The removal event carries the location, conditions and exclusion of the unalias
+// Returns false if no such alias is currently defined, which the shell reports as an error
+func (r *ScanResult) RemoveAlias(key string, removal AliasDefinition) bool {
+	entry, exists := r.Aliases[key]
+	if !exists || entry.IsRemoved || entry.IsExcluded {
+		return false
+	}
+
+	removal.Name = entry.Name
+	removal.Type = entry.Type
+	removal.Removed = true
+	entry.AddDefinition(removal)
+	return true
+}
+
+// CycleOf returns the cycle an alias belongs to, or nil
+func (r *ScanResult) CycleOf(name string) *AliasCycle {
+	for i, cycle := range r.Cycles {
+		for _, member := range cycle.Members {
+			if member.Name == name {
+				return &r.Cycles[i]
+			}
+		}
+	}
+	return nil
+}
+
+// AddFunction adds or updates a function in the scan result
+func (r *ScanResult) AddFunction(def FunctionDefinition) {
+	if entry, exists := r.Functions[def.Name]; exists {
+		entry.AddDefinition(def)
+	} else {
+		r.Functions[def.Name] = &FunctionEntry{
+			Name:           def.Name,
+			ActiveBody:     def.Body,
+			ActiveLocation: def.Location,
+			Definitions:    []FunctionDefinition{def},
+			IsOverridden:   false,
 		}
 	}
 }
@@ -101,3 +407,31 @@ func (r *ScanResult) GetAliasesSorted() []*AliasEntry {
 	}
 	return aliases
 }
+
+// GetDynamicIncludes returns the dynamic includes of all files, sorted by file and line
+func (r *ScanResult) GetDynamicIncludes() []DynamicInclude {
+	dynamic := make([]DynamicInclude, 0)
+	for _, file := range r.Files {
+		dynamic = append(dynamic, file.Dynamic...)
+	}
+	sort.Slice(dynamic, func(i, j int) bool {
+		a, b := dynamic[i].Location, dynamic[j].Location
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		return a.LineNum < b.LineNum
+	})
+	return dynamic
+}
+
+// GetFunctionsSorted returns all functions sorted by name
+func (r *ScanResult) GetFunctionsSorted() []*FunctionEntry {
+	functions := make([]*FunctionEntry, 0, len(r.Functions))
+	for _, entry := range r.Functions {
+		functions = append(functions, entry)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		return functions[i].Name < functions[j].Name
+	})
+	return functions
+}
@@ -8,177 +8,269 @@ import (
 )
 
 var (
-	// Regex patterns for alias detection
-	// Matches: alias name=value or alias name='value' or alias name="value"
-	// Allow letters, numbers, underscores, dots, dashes in alias names
-	normalAliasPattern = regexp.MustCompile(`^\s*alias\s+([a-zA-Z_.\-][a-zA-Z0-9_.\-]*)=(.+)`)
-	// Matches: alias -g name=value
-	globalAliasPattern = regexp.MustCompile(`^\s*alias\s+-g\s+([a-zA-Z_.\-][a-zA-Z0-9_.\-]*)=(.+)`)
-)
+	// Alias names may contain anything except whitespace, quotes, the = sign
+	// and characters the shell treats specially in a command word
+	aliasNamePattern = regexp.MustCompile("^[^\\s=/$`\\\\'\";&|<>()]+$")
 
-// AliasParser handles parsing of alias definitions from shell script lines
-type AliasParser struct{}
+	// Matches assignments to the shells' alias tables: BASH_ALIASES[name]=value
+	// in bash, aliases[name]=value, galiases[...] and saliases[...] in zsh
+	aliasArrayPattern = regexp.MustCompile(`(?s)^(BASH_ALIASES|aliases|galiases|saliases)\[([^\]]+)\]=(.*)$`)
+)
 
-// NewAliasParser creates a new alias parser
-func NewAliasParser() *AliasParser {
-	return &AliasParser{}
+// aliasArray is an alias table: the shell that has it and the type of alias
+// it stores. In other shells the name is an ordinary array.
+type aliasArray struct {
+	shell     string
+	aliasType model.AliasType
 }
 
-// ParseLine attempts to parse an alias definition from a line
-// Returns the alias definition and true if successful, or nil and false otherwise
-func (p *AliasParser) ParseLine(line string, filePath string, lineNum int) (*model.AliasDefinition, bool) {
-	// Remove inline comments (but respect quotes)
-	cleaned := removeInlineComment(line)
-	if cleaned == "" {
-		return nil, false
-	}
+// aliasArrays maps each alias table name to its shell and alias type
+var aliasArrays = map[string]aliasArray{
+	"BASH_ALIASES": {shell: "bash", aliasType: model.AliasTypeNormal},
+	"aliases":      {shell: "zsh", aliasType: model.AliasTypeNormal},
+	"galiases":     {shell: "zsh", aliasType: model.AliasTypeGlobal},
+	"saliases":     {shell: "zsh", aliasType: model.AliasTypeSuffix},
+}
 
-	// Try global alias first (more specific pattern)
-	if matches := globalAliasPattern.FindStringSubmatch(cleaned); matches != nil {
-		return &model.AliasDefinition{
-			Name:  matches[1],
-			Value: extractValue(matches[2]),
-			Type:  model.AliasTypeGlobal,
-			Location: model.SourceLocation{
-				FilePath: filePath,
-				LineNum:  lineNum,
-				RawLine:  line,
-			},
-		}, true
-	}
+// isAliasArray reports whether name is an alias table of shell
+func isAliasArray(name string, shell string) bool {
+	array, ok := aliasArrays[name]
+	return ok && array.shell == shell
+}
 
-	// Try normal alias
-	if matches := normalAliasPattern.FindStringSubmatch(cleaned); matches != nil {
-		return &model.AliasDefinition{
-			Name:  matches[1],
-			Value: extractValue(matches[2]),
-			Type:  model.AliasTypeNormal,
-			Location: model.SourceLocation{
-				FilePath: filePath,
-				LineNum:  lineNum,
-				RawLine:  line,
-			},
-		}, true
-	}
+// AliasParser handles parsing of alias definitions from shell script lines
+type AliasParser struct {
+	shell string // Shell whose alias tables are recognized
+}
 
-	return nil, false
+// NewAliasParser creates a new alias parser for the files of shell
+func NewAliasParser(shell string) *AliasParser {
+	return &AliasParser{shell: shell}
 }
 
-// extractValue extracts the value from an alias assignment, removing quotes and inline comments
-func extractValue(s string) string {
-	s = strings.TrimSpace(s)
+// ParseLine parses every alias definition on a logical line starting at lineNum
+// A single statement may define several aliases (alias ll='ls -l' la='ls -a'),
+// and several statements may share a line; all of them share the same location
+func (p *AliasParser) ParseLine(line string, filePath string, lineNum int) []model.AliasDefinition {
+	defs := make([]model.AliasDefinition, 0)
 
-	// If it starts with a quote, find the matching closing quote
-	if len(s) > 0 && (s[0] == '\'' || s[0] == '"') {
-		quote := s[0]
-		// Find the matching closing quote
-		for i := 1; i < len(s); i++ {
-			if s[i] == quote {
-				// Check if it's escaped
-				if i > 0 && s[i-1] == '\\' {
-					continue
-				}
-				return s[1:i]
-			}
-		}
-		// No closing quote found, return as-is without the opening quote
-		return s[1:]
-	}
+	// An unterminated quote still yields the words read so far, which matches
+	// what the user most likely meant
+	words, _ := Tokenize(line)
+
+	location := newLocation(line, filePath, lineNum)
 
-	// No quotes, remove any trailing comment
-	// Split on # but only if not escaped
-	parts := splitOnUnescapedChar(s, '#')
-	if len(parts) > 0 {
-		return strings.TrimSpace(parts[0])
+	for _, cmd := range SplitCommands(words) {
+		defs = append(defs, p.parseAliasCommand(cmd, location)...)
 	}
 
-	return s
+	return defs
 }
 
-// removeInlineComment removes comments from a line, respecting quotes
-func removeInlineComment(line string) string {
-	inSingleQuote := false
-	inDoubleQuote := false
-	escaped := false
+// Unalias represents a single unalias statement
+type Unalias struct {
+	Names    []string        // Aliases removed by name
+	Patterns []string        // Patterns matched against alias names (zsh unalias -m)
+	All      bool            // unalias -a removes every alias
+	Type     model.AliasType // Namespace the statement operates on (unalias -s for suffix aliases)
+	Location model.SourceLocation
+}
 
-	var result strings.Builder
+// ParseUnalias parses every unalias statement on a logical line starting at lineNum
+func (p *AliasParser) ParseUnalias(line string, filePath string, lineNum int) []Unalias {
+	stmts := make([]Unalias, 0)
 
-	for i := 0; i < len(line); i++ {
-		ch := line[i]
+	words, _ := Tokenize(line)
+	location := newLocation(line, filePath, lineNum)
 
-		if escaped {
-			result.WriteByte(ch)
-			escaped = false
+	for _, cmd := range SplitCommands(words) {
+		if len(cmd) < 2 || cmd[0].Value != "unalias" {
 			continue
 		}
 
-		if ch == '\\' {
-			escaped = true
-			result.WriteByte(ch)
-			continue
+		stmt := Unalias{Type: model.AliasTypeNormal, Location: location}
+		usePatterns := false
+		args := cmd[1:]
+
+		for len(args) > 0 && strings.HasPrefix(args[0].Value, "-") {
+			flag := args[0].Value
+			args = args[1:]
+			if flag == "--" {
+				break
+			}
+			stmt.All = stmt.All || strings.Contains(flag, "a")
+			if strings.Contains(flag, "s") {
+				stmt.Type = model.AliasTypeSuffix
+			}
+			usePatterns = usePatterns || strings.Contains(flag, "m")
 		}
 
-		if ch == '\'' && !inDoubleQuote {
-			inSingleQuote = !inSingleQuote
-			result.WriteByte(ch)
-			continue
+		for _, arg := range args {
+			if usePatterns {
+				stmt.Patterns = append(stmt.Patterns, arg.Value)
+			} else {
+				stmt.Names = append(stmt.Names, arg.Value)
+			}
 		}
 
-		if ch == '"' && !inSingleQuote {
-			inDoubleQuote = !inDoubleQuote
-			result.WriteByte(ch)
-			continue
+		if stmt.All || len(stmt.Names) > 0 || len(stmt.Patterns) > 0 {
+			stmts = append(stmts, stmt)
 		}
+	}
+
+	return stmts
+}
 
-		if ch == '#' && !inSingleQuote && !inDoubleQuote {
+// newLocation builds the source location of a logical line starting at lineNum
+func newLocation(line string, filePath string, lineNum int) model.SourceLocation {
+	return model.SourceLocation{
+		FilePath: filePath,
+		LineNum:  lineNum,
+		EndLine:  lineNum + strings.Count(line, "\n"),
+		RawLine:  line,
+	}
+}
+
+// parseAliasCommand parses a tokenized `alias` command or alias table assignment
+func (p *AliasParser) parseAliasCommand(cmd []Word, location model.SourceLocation) []model.AliasDefinition {
+	if len(cmd) > 0 && aliasArrayPattern.MatchString(cmd[0].Value) {
+		return p.parseAliasAssignments(cmd, location)
+	}
+
+	if len(cmd) < 2 || cmd[0].Value != "alias" {
+		return nil
+	}
+
+	args := cmd[1:]
+
+	// Consume option flags such as -g, -s or -- before the assignments
+	var flags []string
+	for len(args) > 0 && isOptionWord(args[0].Value) {
+		flag := args[0].Value
+		args = args[1:]
+		if flag == "--" {
 			break
 		}
+		flags = append(flags, flag)
+	}
+
+	aliasType, ok := aliasTypeFromFlags(flags)
+	if !ok {
+		return nil
+	}
+
+	var defs []model.AliasDefinition
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg.Value, "=")
+		if !ok || !aliasNamePattern.MatchString(name) {
+			// A bare name only prints the alias
+			continue
+		}
 
-		result.WriteByte(ch)
+		defs = append(defs, model.AliasDefinition{
+			Name:     name,
+			Value:    value,
+			RawValue: rawValue(arg.Raw),
+			Type:     aliasType,
+			Location: location,
+		})
 	}
 
-	return strings.TrimSpace(result.String())
+	return defs
 }
 
-// splitOnUnescapedChar splits a string on a character that is not escaped
-func splitOnUnescapedChar(s string, delim byte) []string {
-	var parts []string
-	var current strings.Builder
-	escaped := false
+// parseAliasAssignments parses the leading alias table assignments of a command,
+// such as BASH_ALIASES[ll]='ls -l' or galiases[G]='| grep'
+func (p *AliasParser) parseAliasAssignments(cmd []Word, location model.SourceLocation) []model.AliasDefinition {
+	var defs []model.AliasDefinition
 
-	for i := 0; i < len(s); i++ {
-		ch := s[i]
+	for _, word := range cmd {
+		matches := aliasArrayPattern.FindStringSubmatch(word.Value)
+		if matches == nil || !isAliasArray(matches[1], p.shell) {
+			break
+		}
 
-		if escaped {
-			current.WriteByte(ch)
-			escaped = false
+		name := matches[2]
+		if !aliasNamePattern.MatchString(name) {
+			// Computed keys like aliases[$name] can't be resolved statically
 			continue
 		}
 
-		if ch == '\\' {
-			escaped = true
-			current.WriteByte(ch)
-			continue
+		raw := word.Raw
+		if idx := strings.Index(raw, "]="); idx >= 0 {
+			raw = raw[idx+2:]
 		}
 
-		if ch == delim {
-			parts = append(parts, current.String())
-			current.Reset()
-			continue
+		defs = append(defs, model.AliasDefinition{
+			Name:     name,
+			Value:    matches[3],
+			RawValue: raw,
+			Type:     aliasArrays[matches[1]].aliasType,
+			Location: location,
+		})
+	}
+
+	return defs
+}
+
+// isOptionWord reports whether an alias argument is an option cluster rather than
+// an assignment. zsh accepts both -x and +x forms.
+func isOptionWord(value string) bool {
+	return len(value) > 1 && (value[0] == '-' || value[0] == '+') && !strings.Contains(value, "=")
+}
+
+// aliasTypeFromFlags determines the alias type selected by zsh option clusters
+// -r selects a regular alias, -g a global one and -s a suffix alias. Like zsh,
+// combining more than one of them (alias -gr) is rejected and defines nothing.
+func aliasTypeFromFlags(flags []string) (model.AliasType, bool) {
+	aliasType := model.AliasTypeNormal
+	typeFlags := 0
+
+	for _, flag := range flags {
+		for _, opt := range flag[1:] {
+			switch opt {
+			case 'r':
+				aliasType = model.AliasTypeNormal
+			case 'g':
+				aliasType = model.AliasTypeGlobal
+			case 's':
+				aliasType = model.AliasTypeSuffix
+			default:
+				continue
+			}
+			typeFlags++
 		}
+	}
+
+	return aliasType, typeFlags <= 1
+}
 
-		current.WriteByte(ch)
+// rawValue returns the source text to the right of the first = in a word
+func rawValue(raw string) string {
+	if idx := strings.IndexByte(raw, '='); idx >= 0 {
+		return raw[idx+1:]
 	}
+	return raw
+}
 
-	if current.Len() > 0 {
-		parts = append(parts, current.String())
+// IsAliasLine quickly checks if a line of a file of shell might contain an
+// alias definition
+func IsAliasLine(line string, shell string) bool {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "alias ") {
+		return true
 	}
 
-	return parts
+	for table := range aliasArrays {
+		if isAliasArray(table, shell) && strings.HasPrefix(trimmed, table+"[") {
+			return true
+		}
+	}
+	return false
 }
 
-// IsAliasLine quickly checks if a line might contain an alias definition
-func IsAliasLine(line string) bool {
+// IsUnaliasLine quickly checks if a line might contain an unalias statement
+func IsUnaliasLine(line string) bool {
 	trimmed := strings.TrimSpace(line)
-	return strings.HasPrefix(trimmed, "alias ")
+	return strings.HasPrefix(trimmed, "unalias ")
 }
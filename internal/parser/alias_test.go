@@ -1,16 +1,16 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/oscar.rivas/falias/internal/model"
 )
 
 func TestParseAliasVariations(t *testing.T) {
-	parser := NewAliasParser()
-
 	tests := []struct {
 		name      string
+		shell     string // Shell of the file, zsh if empty
 		line      string
 		wantName  string
 		wantValue string
@@ -35,12 +35,68 @@ func TestParseAliasVariations(t *testing.T) {
 		},
 		{
 			name:      "no quotes",
-			line:      "alias ..=cd ..",
+			line:      "alias ..='cd ..'",
 			wantName:  "..",
 			wantValue: "cd ..",
 			wantType:  model.AliasTypeNormal,
 			wantOk:    true,
 		},
+		{
+			name:      "unquoted value stops at whitespace",
+			line:      "alias ..=cd ..",
+			wantName:  "..",
+			wantValue: "cd",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:      "escaped single quote",
+			line:      `alias say='echo '\''hi'\'''`,
+			wantName:  "say",
+			wantValue: "echo 'hi'",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:      "ansi-c string",
+			line:      `alias tabs=$'printf \'a\\tb\''`,
+			wantName:  "tabs",
+			wantValue: "printf 'a\\tb'",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:      "concatenated quoted segments",
+			line:      `alias gl='git log '"--oneline"`,
+			wantName:  "gl",
+			wantValue: "git log --oneline",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:      "backslash escaped spaces",
+			line:      `alias docs=cd\ ~/My\ Documents`,
+			wantName:  "docs",
+			wantValue: "cd ~/My Documents",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:      "double quotes keep expansions",
+			line:      `alias here="cd \"$PWD\" && $(pwd)"`,
+			wantName:  "here",
+			wantValue: `cd "$PWD" && $(pwd)`,
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:      "after a test guard",
+			line:      "[[ -x /usr/bin/rg ]] && alias grep=rg",
+			wantName:  "grep",
+			wantValue: "rg",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
 		{
 			name:      "with inline comment",
 			line:      "alias grep='grep --color=auto' # colorize grep",
@@ -57,6 +113,27 @@ func TestParseAliasVariations(t *testing.T) {
 			wantType:  model.AliasTypeGlobal,
 			wantOk:    true,
 		},
+		{
+			name:      "suffix alias",
+			line:      "alias -s md=glow",
+			wantName:  "md",
+			wantValue: "glow",
+			wantType:  model.AliasTypeSuffix,
+			wantOk:    true,
+		},
+		{
+			name:      "explicit regular alias",
+			line:      "alias -r -- -='cd -'",
+			wantName:  "-",
+			wantValue: "cd -",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:   "conflicting type flags",
+			line:   "alias -gr L='| less'",
+			wantOk: false,
+		},
 		{
 			name:      "with leading whitespace",
 			line:      "  alias foo='bar'",
@@ -81,6 +158,66 @@ func TestParseAliasVariations(t *testing.T) {
 			wantType:  model.AliasTypeNormal,
 			wantOk:    true,
 		},
+		{
+			name:      "bash alias table",
+			shell:     "bash",
+			line:      "BASH_ALIASES[ll]='ls -l'",
+			wantName:  "ll",
+			wantValue: "ls -l",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:      "zsh alias table with quoted key",
+			line:      `aliases['gst']="git status"`,
+			wantName:  "gst",
+			wantValue: "git status",
+			wantType:  model.AliasTypeNormal,
+			wantOk:    true,
+		},
+		{
+			name:      "zsh global alias table",
+			line:      "galiases[G]='| grep'",
+			wantName:  "G",
+			wantValue: "| grep",
+			wantType:  model.AliasTypeGlobal,
+			wantOk:    true,
+		},
+		{
+			name:      "zsh suffix alias table",
+			line:      "saliases[pdf]=zathura",
+			wantName:  "pdf",
+			wantValue: "zathura",
+			wantType:  model.AliasTypeSuffix,
+			wantOk:    true,
+		},
+		{
+			name:   "bash alias table in zsh",
+			line:   "BASH_ALIASES[ll]='ls -l'",
+			wantOk: false,
+		},
+		{
+			name:   "zsh alias table in bash",
+			shell:  "bash",
+			line:   "aliases[ll]='ls -l'",
+			wantOk: false,
+		},
+		{
+			name:   "zsh global alias table in bash",
+			shell:  "bash",
+			line:   "galiases[G]='| grep'",
+			wantOk: false,
+		},
+		{
+			name:   "computed alias table key",
+			line:   `aliases[$name]=value`,
+			wantOk: false,
+		},
+		{
+			name:   "unrelated array",
+			line:   "colors[red]=1",
+			wantOk: false,
+		},
 		{
 			name:   "comment line",
 			line:   "# alias foo='bar'",
@@ -95,7 +232,12 @@ func TestParseAliasVariations(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			def, ok := parser.ParseLine(tt.line, "/test/file", 1)
+			shell := tt.shell
+			if shell == "" {
+				shell = "zsh"
+			}
+			defs := NewAliasParser(shell).ParseLine(tt.line, "/test/file", 1)
+			ok := len(defs) > 0
 
 			if ok != tt.wantOk {
 				t.Errorf("ParseLine() ok = %v, want %v", ok, tt.wantOk)
@@ -106,6 +248,7 @@ func TestParseAliasVariations(t *testing.T) {
 				return
 			}
 
+			def := defs[0]
 			if def.Name != tt.wantName {
 				t.Errorf("ParseLine() name = %v, want %v", def.Name, tt.wantName)
 			}
@@ -121,23 +264,164 @@ func TestParseAliasVariations(t *testing.T) {
 	}
 }
 
+func TestParseAliasRawValue(t *testing.T) {
+	parser := NewAliasParser("zsh")
+
+	defs := parser.ParseLine(`alias gl='git log '"--oneline" # short log`, "/test/file", 1)
+	if len(defs) != 1 {
+		t.Fatalf("ParseLine() returned %d definitions, want 1", len(defs))
+	}
+
+	def := defs[0]
+
+	if want := `'git log '"--oneline"`; def.RawValue != want {
+		t.Errorf("ParseLine() raw value = %v, want %v", def.RawValue, want)
+	}
+
+	if want := "git log --oneline"; def.Value != want {
+		t.Errorf("ParseLine() value = %v, want %v", def.Value, want)
+	}
+}
+
+func TestParseMultipleAliases(t *testing.T) {
+	parser := NewAliasParser("zsh")
+
+	tests := []struct {
+		name      string
+		line      string
+		wantNames []string
+		wantVals  []string
+	}{
+		{
+			name:      "several assignments in one statement",
+			line:      "alias ll='ls -l' la='ls -a' l='ls -CF'",
+			wantNames: []string{"ll", "la", "l"},
+			wantVals:  []string{"ls -l", "ls -a", "ls -CF"},
+		},
+		{
+			name:      "query mixed with assignments",
+			line:      "alias ll la='ls -a'",
+			wantNames: []string{"la"},
+			wantVals:  []string{"ls -a"},
+		},
+		{
+			name:      "several statements on one line",
+			line:      "alias g=git; alias -g L='| less'",
+			wantNames: []string{"g", "L"},
+			wantVals:  []string{"git", "| less"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs := parser.ParseLine(tt.line, "/test/file", 7)
+
+			if len(defs) != len(tt.wantNames) {
+				t.Fatalf("ParseLine() returned %d definitions, want %d", len(defs), len(tt.wantNames))
+			}
+
+			for i, def := range defs {
+				if def.Name != tt.wantNames[i] || def.Value != tt.wantVals[i] {
+					t.Errorf("ParseLine()[%d] = %s=%s, want %s=%s", i, def.Name, def.Value, tt.wantNames[i], tt.wantVals[i])
+				}
+				if def.Location.LineNum != 7 || def.Location.RawLine != tt.line {
+					t.Errorf("ParseLine()[%d] location = %+v, want line 7 of the same raw line", i, def.Location)
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnalias(t *testing.T) {
+	parser := NewAliasParser("zsh")
+
+	tests := []struct {
+		name         string
+		line         string
+		wantNames    []string
+		wantPatterns []string
+		wantAll      bool
+		wantOk       bool
+	}{
+		{
+			name:      "single name",
+			line:      "unalias gco",
+			wantNames: []string{"gco"},
+			wantOk:    true,
+		},
+		{
+			name:      "several names",
+			line:      "unalias ll la 2>/dev/null",
+			wantNames: []string{"ll", "la"},
+			wantOk:    true,
+		},
+		{
+			name:    "remove all",
+			line:    "unalias -a",
+			wantAll: true,
+			wantOk:  true,
+		},
+		{
+			name:         "zsh pattern",
+			line:         "unalias -m 'g*'",
+			wantPatterns: []string{"g*"},
+			wantOk:       true,
+		},
+		{
+			name:   "not an unalias",
+			line:   "alias unalias=true",
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts := parser.ParseUnalias(tt.line, "/test/file", 3)
+
+			if (len(stmts) > 0) != tt.wantOk {
+				t.Fatalf("ParseUnalias() returned %d statements, wantOk %v", len(stmts), tt.wantOk)
+			}
+
+			if !tt.wantOk {
+				return
+			}
+
+			stmt := stmts[0]
+			if strings.Join(stmt.Names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("ParseUnalias() names = %v, want %v", stmt.Names, tt.wantNames)
+			}
+			if strings.Join(stmt.Patterns, ",") != strings.Join(tt.wantPatterns, ",") {
+				t.Errorf("ParseUnalias() patterns = %v, want %v", stmt.Patterns, tt.wantPatterns)
+			}
+			if stmt.All != tt.wantAll {
+				t.Errorf("ParseUnalias() all = %v, want %v", stmt.All, tt.wantAll)
+			}
+		})
+	}
+}
+
 func TestIsAliasLine(t *testing.T) {
 	tests := []struct {
-		line string
-		want bool
+		line  string
+		shell string
+		want  bool
 	}{
-		{"alias foo='bar'", true},
-		{"  alias foo='bar'", true},
-		{"alias -g G='grep'", true},
-		{"# alias foo='bar'", false},
-		{"export FOO=bar", false},
-		{"", false},
-		{"source ~/.bashrc", false},
+		{"alias foo='bar'", "bash", true},
+		{"  alias foo='bar'", "zsh", true},
+		{"alias -g G='grep'", "zsh", true},
+		{"BASH_ALIASES[ll]='ls -l'", "bash", true},
+		{"BASH_ALIASES[ll]='ls -l'", "zsh", false},
+		{"galiases[G]='| grep'", "zsh", true},
+		{"galiases[G]='| grep'", "bash", false},
+		{"# alias foo='bar'", "bash", false},
+		{"export FOO=bar", "bash", false},
+		{"", "bash", false},
+		{"source ~/.bashrc", "bash", false},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.line, func(t *testing.T) {
-			if got := IsAliasLine(tt.line); got != tt.want {
+		t.Run(tt.shell+" "+tt.line, func(t *testing.T) {
+			if got := IsAliasLine(tt.line, tt.shell); got != tt.want {
 				t.Errorf("IsAliasLine() = %v, want %v", got, tt.want)
 			}
 		})
@@ -0,0 +1,333 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+)
+
+var (
+	// abbr options that take a value as the next argument
+	abbrValueOptions = map[string]bool{
+		"-p": true, "--position": true,
+		"-r": true, "--regex": true,
+		"-f": true, "--function": true,
+		"-c": true, "--command": true,
+	}
+)
+
+// FishParser handles parsing of alias and abbreviation definitions in fish syntax:
+//
+//	alias name 'value'
+//	alias name='value'
+//	abbr -a name value
+type FishParser struct{}
+
+// NewFishParser creates a new fish parser
+func NewFishParser() *FishParser {
+	return &FishParser{}
+}
+
+// ParseLine parses every alias and abbreviation definition on a logical line starting at lineNum
+func (p *FishParser) ParseLine(line string, filePath string, lineNum int) []model.AliasDefinition {
+	defs := make([]model.AliasDefinition, 0)
+
+	words, _ := TokenizeDialect(line, DialectFish)
+	location := newLocation(line, filePath, lineNum)
+
+	for _, cmd := range splitFishCommands(words) {
+		if len(cmd) < 2 {
+			continue
+		}
+
+		switch cmd[0].Value {
+		case "alias":
+			if def, ok := parseFishAlias(cmd[1:], location); ok {
+				defs = append(defs, def)
+			}
+		case "abbr":
+			if def, ok := parseFishAbbr(cmd[1:], location); ok {
+				defs = append(defs, def)
+			}
+		}
+	}
+
+	return defs
+}
+
+// ParseErase parses `abbr --erase` statements on a logical line starting at lineNum
+func (p *FishParser) ParseErase(line string, filePath string, lineNum int) []Unalias {
+	stmts := make([]Unalias, 0)
+
+	words, _ := TokenizeDialect(line, DialectFish)
+	location := newLocation(line, filePath, lineNum)
+
+	for _, cmd := range splitFishCommands(words) {
+		if len(cmd) < 3 || cmd[0].Value != "abbr" {
+			continue
+		}
+
+		stmt := Unalias{Type: model.AliasTypeAbbreviation, Location: location}
+		erase := false
+		for _, arg := range cmd[1:] {
+			switch {
+			case arg.Value == "-e" || arg.Value == "--erase":
+				erase = true
+			case strings.HasPrefix(arg.Value, "-"):
+				continue
+			default:
+				stmt.Names = append(stmt.Names, arg.Value)
+			}
+		}
+
+		if erase && len(stmt.Names) > 0 {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	return stmts
+}
+
+// parseFishAlias parses the arguments of a fish alias command
+// Like fish, a single argument is split at the first =, otherwise the
+// remaining arguments are joined into the value
+func parseFishAlias(args []Word, location model.SourceLocation) (model.AliasDefinition, bool) {
+	for len(args) > 0 && strings.HasPrefix(args[0].Value, "-") {
+		if args[0].Value == "--" {
+			args = args[1:]
+			break
+		}
+		args = args[1:] // --save and friends
+	}
+
+	var name, value, raw string
+	switch {
+	case len(args) == 1:
+		var ok bool
+		if name, value, ok = strings.Cut(args[0].Value, "="); !ok {
+			return model.AliasDefinition{}, false
+		}
+		raw = rawValue(args[0].Raw)
+	case len(args) > 1:
+		name = args[0].Value
+		value, raw = joinWords(args[1:])
+	default:
+		return model.AliasDefinition{}, false
+	}
+
+	if !aliasNamePattern.MatchString(name) {
+		return model.AliasDefinition{}, false
+	}
+
+	return model.AliasDefinition{
+		Name:     name,
+		Value:    value,
+		RawValue: raw,
+		Type:     model.AliasTypeNormal,
+		Location: location,
+	}, true
+}
+
+// parseFishAbbr parses the arguments of a fish abbr command that adds an abbreviation
+// Both `abbr -a name value` and the fish 3.6 form `abbr name value` are accepted
+func parseFishAbbr(args []Word, location model.SourceLocation) (model.AliasDefinition, bool) {
+	var positional []Word
+	function := ""
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i].Value
+
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case arg == "-a" || arg == "--add" || arg == "-g" || arg == "--global" ||
+			arg == "-U" || arg == "--universal" || strings.HasPrefix(arg, "--set-cursor"):
+			continue
+		case abbrValueOptions[arg]:
+			if i+1 < len(args) && (arg == "-f" || arg == "--function") {
+				function = args[i+1].Value
+			}
+			i++
+		case strings.HasPrefix(arg, "--function="):
+			function = strings.TrimPrefix(arg, "--function=")
+		case strings.HasPrefix(arg, "-"):
+			// Listing, erasing, renaming and querying don't define anything
+			return model.AliasDefinition{}, false
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+
+	if len(positional) == 0 || (len(positional) == 1 && function == "") {
+		return model.AliasDefinition{}, false
+	}
+
+	name := positional[0].Value
+	if !aliasNamePattern.MatchString(name) {
+		return model.AliasDefinition{}, false
+	}
+
+	value, raw := function, function
+	if len(positional) > 1 {
+		value, raw = joinWords(positional[1:])
+	}
+
+	return model.AliasDefinition{
+		Name:     name,
+		Value:    value,
+		RawValue: raw,
+		Type:     model.AliasTypeAbbreviation,
+		Location: location,
+	}, true
+}
+
+// FishFunctionAlias recognizes a function written by `alias --save` or funcsave
+// for an alias, whose header carries --description 'alias name=value'
+func FishFunctionAlias(def model.FunctionDefinition) (model.AliasDefinition, bool) {
+	words, _ := TokenizeDialect(def.Location.RawLine, DialectFish)
+
+	for i, w := range words {
+		description := ""
+		switch {
+		case (w.Value == "-d" || w.Value == "--description") && i+1 < len(words):
+			description = words[i+1].Value
+		case strings.HasPrefix(w.Value, "--description="):
+			description = strings.TrimPrefix(w.Value, "--description=")
+		default:
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimPrefix(description, "alias "), "=")
+		if !strings.HasPrefix(description, "alias ") || !ok || name != def.Name {
+			return model.AliasDefinition{}, false
+		}
+
+		return model.AliasDefinition{
+			Name:     name,
+			Value:    value,
+			RawValue: value,
+			Type:     model.AliasTypeNormal,
+			Location: def.Location,
+		}, true
+	}
+
+	return model.AliasDefinition{}, false
+}
+
+// FishFunctionParser finds fish function definitions (function name ... end)
+// Like FunctionParser it keeps state between calls and must be fed every
+// logical line of a file in order.
+type FishFunctionParser struct {
+	current *model.FunctionDefinition // Definition whose body is still open
+	lines   []string                  // Source lines of the current definition
+	depth   int                       // Nesting depth of blocks closed by end
+}
+
+// NewFishFunctionParser creates a new fish function parser
+func NewFishFunctionParser() *FishFunctionParser {
+	return &FishFunctionParser{}
+}
+
+// ParseLine feeds the next logical line, starting at lineNum, and returns the
+// function definitions completed on it
+func (p *FishFunctionParser) ParseLine(line string, filePath string, lineNum int) []model.FunctionDefinition {
+	var done []model.FunctionDefinition
+
+	if p.current != nil {
+		p.lines = append(p.lines, line)
+	}
+
+	words, _ := TokenizeDialect(line, DialectFish)
+	cmdStart := true
+	prev := ""
+
+	for i, w := range words {
+		if cmdStart && w.Kind == WordLiteral && w.Raw == w.Value {
+			switch w.Value {
+			case "function":
+				if p.current == nil {
+					if i+1 >= len(words) || words[i+1].Kind != WordLiteral || !functionNamePattern.MatchString(words[i+1].Value) {
+						break
+					}
+					p.current = &model.FunctionDefinition{
+						Name:     words[i+1].Value,
+						Location: newLocation(line, filePath, lineNum),
+					}
+					p.lines = []string{line}
+				}
+				p.depth++
+			case "if":
+				// else if continues the current block
+				if prev != "else" && p.current != nil {
+					p.depth++
+				}
+			case "for", "while", "switch", "begin":
+				if p.current != nil {
+					p.depth++
+				}
+			case "end":
+				if p.current != nil {
+					p.depth--
+					if p.depth == 0 {
+						def := *p.current
+						def.Body = strings.Join(p.lines, "\n")
+						def.Location.EndLine = lineNum + strings.Count(line, "\n")
+						done = append(done, def)
+						p.current = nil
+						p.lines = nil
+					}
+				}
+			}
+		}
+
+		cmdStart = w.Kind == WordOperator
+		if w.Kind == WordLiteral && w.Raw == w.Value {
+			switch w.Value {
+			case "and", "or", "not", "else", "begin", "command", "builtin":
+				cmdStart = true
+			}
+		}
+		prev = w.Value
+	}
+
+	return done
+}
+
+// Pending returns the definition whose body was still open when input ended
+func (p *FishFunctionParser) Pending() (*model.FunctionDefinition, bool) {
+	return p.current, p.current != nil
+}
+
+// splitFishCommands splits fish words into simple commands, also treating
+// the `and` and `or` combiners as separators
+func splitFishCommands(words []Word) [][]Word {
+	var commands [][]Word
+	for _, cmd := range SplitCommands(words) {
+		for len(cmd) > 0 && (cmd[0].Raw == "and" || cmd[0].Raw == "or" || cmd[0].Raw == "not" || cmd[0].Raw == "command") {
+			cmd = cmd[1:]
+		}
+		if len(cmd) > 0 {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+// joinWords joins argument words the way fish joins $argv: values separated
+// by spaces. The raw text is joined the same way.
+func joinWords(words []Word) (string, string) {
+	values := make([]string, len(words))
+	raws := make([]string, len(words))
+	for i, w := range words {
+		values[i] = w.Value
+		raws[i] = w.Raw
+	}
+	return strings.Join(values, " "), strings.Join(raws, " ")
+}
+
+// IsFishAliasLine quickly checks if a line might contain a fish alias or abbr command
+func IsFishAliasLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "alias ") || strings.HasPrefix(trimmed, "abbr ")
+}
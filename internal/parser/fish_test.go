@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oscar.rivas/falias/internal/model"
+)
+
+func TestParseFishAliases(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantNames []string
+		wantValue string
+		wantType  model.AliasType
+	}{
+		{
+			name:      "alias with separate value",
+			line:      "alias ll 'ls -l'",
+			wantNames: []string{"ll"},
+			wantValue: "ls -l",
+			wantType:  model.AliasTypeNormal,
+		},
+		{
+			name:      "alias with equals",
+			line:      "alias gs='git status'",
+			wantNames: []string{"gs"},
+			wantValue: "git status",
+			wantType:  model.AliasTypeNormal,
+		},
+		{
+			name:      "alias value joined from arguments",
+			line:      "alias --save la ls -a",
+			wantNames: []string{"la"},
+			wantValue: "ls -a",
+			wantType:  model.AliasTypeNormal,
+		},
+		{
+			name:      "fish quote escapes",
+			line:      `alias say 'echo it\'s'`,
+			wantNames: []string{"say"},
+			wantValue: "echo it's",
+			wantType:  model.AliasTypeNormal,
+		},
+		{
+			name:      "abbreviation",
+			line:      "abbr -a gco git checkout",
+			wantNames: []string{"gco"},
+			wantValue: "git checkout",
+			wantType:  model.AliasTypeAbbreviation,
+		},
+		{
+			name:      "abbreviation with options",
+			line:      "abbr --add --position anywhere -- L '| less'",
+			wantNames: []string{"L"},
+			wantValue: "| less",
+			wantType:  model.AliasTypeAbbreviation,
+		},
+		{
+			name:      "abbreviation without -a",
+			line:      "abbr gp git push",
+			wantNames: []string{"gp"},
+			wantValue: "git push",
+			wantType:  model.AliasTypeAbbreviation,
+		},
+		{
+			name:      "abbreviation expanded by a function",
+			line:      "abbr -a !! --function last_history_item",
+			wantNames: []string{"!!"},
+			wantValue: "last_history_item",
+			wantType:  model.AliasTypeAbbreviation,
+		},
+		{
+			name:      "guarded by and",
+			line:      "type -q eza; and alias ls eza",
+			wantNames: []string{"ls"},
+			wantValue: "eza",
+			wantType:  model.AliasTypeNormal,
+		},
+		{
+			name: "abbr query defines nothing",
+			line: "abbr --query gco",
+		},
+		{
+			name: "bare alias prints",
+			line: "alias ll",
+		},
+	}
+
+	p := NewFishParser()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs := p.ParseLine(tt.line, "/test/config.fish", 1)
+
+			var names []string
+			for _, def := range defs {
+				names = append(names, def.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Fatalf("ParseLine() names = %v, want %v", names, tt.wantNames)
+			}
+			if len(defs) == 0 {
+				return
+			}
+
+			if defs[0].Value != tt.wantValue {
+				t.Errorf("ParseLine() value = %q, want %q", defs[0].Value, tt.wantValue)
+			}
+			if defs[0].Type != tt.wantType {
+				t.Errorf("ParseLine() type = %q, want %q", defs[0].Type, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestParseFishErase(t *testing.T) {
+	p := NewFishParser()
+
+	stmts := p.ParseErase("abbr -e gco gp", "/test/config.fish", 3)
+	if len(stmts) != 1 {
+		t.Fatalf("ParseErase() returned %d statements, want 1", len(stmts))
+	}
+	if strings.Join(stmts[0].Names, ",") != "gco,gp" || stmts[0].Type != model.AliasTypeAbbreviation {
+		t.Errorf("ParseErase() = %+v, want abbreviations gco,gp", stmts[0])
+	}
+
+	if stmts := p.ParseErase("abbr -a gco git checkout", "/test/config.fish", 4); len(stmts) != 0 {
+		t.Errorf("ParseErase() on abbr -a = %+v, want none", stmts)
+	}
+}
+
+func TestParseFishFunctions(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []string
+		wantNames []string
+		wantSpans [][2]int
+		wantAlias string
+		wantOpen  bool
+	}{
+		{
+			name:      "nested blocks",
+			lines:     []string{"function mkcd", "  if test -n \"$argv\"", "    mkdir -p $argv; and cd $argv", "  else if true", "  end", "end"},
+			wantNames: []string{"mkcd"},
+			wantSpans: [][2]int{{1, 6}},
+		},
+		{
+			name:      "saved alias",
+			lines:     []string{"function ll --wraps='ls -l' --description 'alias ll=ls -l'", "  ls -l $argv", "end"},
+			wantNames: []string{"ll"},
+			wantSpans: [][2]int{{1, 3}},
+			wantAlias: "ls -l",
+		},
+		{
+			name:      "one-liner",
+			lines:     []string{"function hi; echo hi; end", "function bye; for i in 1; echo $i; end; end"},
+			wantNames: []string{"hi", "bye"},
+			wantSpans: [][2]int{{1, 1}, {2, 2}},
+		},
+		{
+			name:     "unterminated",
+			lines:    []string{"function broken", "  switch $x"},
+			wantOpen: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewFishFunctionParser()
+
+			var fns []model.FunctionDefinition
+			for i, line := range tt.lines {
+				fns = append(fns, parser.ParseLine(line, "/test/config.fish", i+1)...)
+			}
+
+			var names []string
+			for i, fn := range fns {
+				names = append(names, fn.Name)
+				if span := [2]int{fn.Location.LineNum, fn.Location.EndLine}; span != tt.wantSpans[i] {
+					t.Errorf("function %s span = %v, want %v", fn.Name, span, tt.wantSpans[i])
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Fatalf("ParseLine() names = %v, want %v", names, tt.wantNames)
+			}
+
+			if len(fns) > 0 {
+				def, ok := FishFunctionAlias(fns[0])
+				if ok != (tt.wantAlias != "") || def.Value != tt.wantAlias {
+					t.Errorf("FishFunctionAlias() = %q, %v, want %q", def.Value, ok, tt.wantAlias)
+				}
+			}
+
+			if _, open := parser.Pending(); open != tt.wantOpen {
+				t.Errorf("Pending() = %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
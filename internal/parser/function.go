@@ -0,0 +1,252 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+)
+
+var (
+	// Function names are more permissive than variable names in both bash and
+	// zsh (git-co, my.func), but cannot contain quotes, expansions or operators
+	functionNamePattern = regexp.MustCompile("^[^\\s=$`\\\\'\";&|<>(){}]+$")
+)
+
+// FunctionParser finds shell function definitions in bash and zsh syntax:
+//
+//	name() { ... }
+//	name () ( ... )
+//	function name { ... }
+//	function name() { ... }
+//
+// Definitions usually span several lines, so the parser keeps state between
+// calls and must be fed every logical line of a file in order. Use a new
+// parser for each file.
+type FunctionParser struct {
+	current  *model.FunctionDefinition // Definition whose body is still open
+	lines    []string                  // Source lines of the current definition
+	depth    int                       // Nesting depth of the body delimiters
+	closer   string                    // Delimiter that closes the body: } or )
+	awaiting bool                      // Header seen, body opens on a later line
+}
+
+// NewFunctionParser creates a new function parser
+func NewFunctionParser() *FunctionParser {
+	return &FunctionParser{}
+}
+
+// ParseLine feeds the next logical line, starting at lineNum, and returns the
+// function definitions completed on it
+func (p *FunctionParser) ParseLine(line string, filePath string, lineNum int) []model.FunctionDefinition {
+	var done []model.FunctionDefinition
+
+	if p.current != nil {
+		p.lines = append(p.lines, line)
+	}
+
+	words, _ := Tokenize(line)
+	cmdStart := true
+	opened := false // A body opened on this line
+
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+
+		switch {
+		case p.current != nil && p.awaiting:
+			if w.Kind == WordOperator && w.Value == "\n" {
+				continue
+			}
+			if !p.openBody(w) {
+				// Not a function after all
+				p.reset()
+			}
+			opened = p.current != nil
+
+		case p.current != nil:
+			// Braces are reserved words, only delimiters where a command
+			// starts or a compound command ends: echo } doesn't close the body.
+			// zsh also ends a one-line body with a sole } argument: f() { ls }
+			reserved := cmdStart || (i > 0 && endsCompound(words[i-1])) ||
+				(opened && (i+1 == len(words) || words[i+1].Kind == WordOperator))
+			if p.closesBody(w, reserved) {
+				done = append(done, p.finish(line, lineNum))
+			}
+
+		case cmdStart && w.Kind == WordLiteral:
+			if next, ok := p.parseHeader(words, i, line, filePath, lineNum); ok {
+				switch {
+				case next >= len(words):
+					p.awaiting = true
+					return done
+				case p.openBody(words[next]):
+					i = next
+					opened = true
+				default:
+					// zsh allows a simple command as the body: name() echo hi
+					i = endOfCommand(words, next)
+					done = append(done, p.finish(line, lineNum))
+				}
+			}
+		}
+
+		cmdStart = startsCommand(words[i])
+	}
+
+	return done
+}
+
+// Pending returns the definition whose body was still open when input ended
+func (p *FunctionParser) Pending() (*model.FunctionDefinition, bool) {
+	if p.current == nil || p.awaiting {
+		return nil, false
+	}
+	return p.current, true
+}
+
+// parseHeader recognizes a function header starting at words[i]
+// It returns the index of the first token after the header
+func (p *FunctionParser) parseHeader(words []Word, i int, line string, filePath string, lineNum int) (int, bool) {
+	var name string
+	next := i
+
+	if words[i].Value == "function" && words[i].Raw == "function" {
+		if i+1 >= len(words) || words[i+1].Kind != WordLiteral {
+			return 0, false
+		}
+		name = words[i+1].Value
+		next = i + 2
+		if hasEmptyParens(words, next) {
+			next += 2
+		}
+	} else if hasEmptyParens(words, i+1) {
+		name = words[i].Value
+		next = i + 3
+	} else {
+		return 0, false
+	}
+
+	if !functionNamePattern.MatchString(name) {
+		return 0, false
+	}
+
+	// Skip a newline between the header and the body within the same logical line
+	for next < len(words) && words[next].Kind == WordOperator && words[next].Value == "\n" {
+		next++
+	}
+
+	p.current = &model.FunctionDefinition{
+		Name:     name,
+		Location: newLocation(line, filePath, lineNum),
+	}
+	p.lines = []string{line}
+	return next, true
+}
+
+// openBody starts the function body if w is a body delimiter
+func (p *FunctionParser) openBody(w Word) bool {
+	switch {
+	case w.Kind == WordLiteral && w.Raw == "{":
+		p.closer = "}"
+	case w.Kind == WordOperator && w.Value == "(":
+		p.closer = ")"
+	default:
+		return false
+	}
+
+	p.depth = 1
+	p.awaiting = false
+	return true
+}
+
+// closesBody tracks delimiter nesting and reports whether w closes the body
+// reserved tells whether w is where braces are reserved words
+func (p *FunctionParser) closesBody(w Word, reserved bool) bool {
+	if p.closer == "}" {
+		if w.Kind != WordLiteral || !reserved {
+			return false
+		}
+		switch w.Raw {
+		case "{":
+			p.depth++
+		case "}":
+			p.depth--
+		}
+	} else {
+		if w.Kind != WordOperator {
+			return false
+		}
+		switch w.Value {
+		case "(":
+			p.depth++
+		case ")":
+			p.depth--
+		}
+	}
+
+	return p.depth == 0
+}
+
+// finish completes the current definition, ending on the line that started at lineNum
+func (p *FunctionParser) finish(line string, lineNum int) model.FunctionDefinition {
+	def := *p.current
+	def.Body = strings.Join(p.lines, "\n")
+	def.Location.EndLine = lineNum + strings.Count(line, "\n")
+	p.reset()
+	return def
+}
+
+// reset forgets the current definition
+func (p *FunctionParser) reset() {
+	p.current = nil
+	p.lines = nil
+	p.depth = 0
+	p.closer = ""
+	p.awaiting = false
+}
+
+// hasEmptyParens reports whether words[i:] starts with the ( ) operator pair
+func hasEmptyParens(words []Word, i int) bool {
+	return i+1 < len(words) &&
+		words[i].Kind == WordOperator && words[i].Value == "(" &&
+		words[i+1].Kind == WordOperator && words[i+1].Value == ")"
+}
+
+// endOfCommand returns the index of the last word of the simple command starting at i
+func endOfCommand(words []Word, i int) int {
+	for i+1 < len(words) && words[i+1].Kind != WordOperator {
+		i++
+	}
+	return i
+}
+
+// endsCompound reports whether w closes a compound command, after which a
+// closing brace is still a reserved word: if ...; fi }
+func endsCompound(w Word) bool {
+	if w.Kind == WordOperator {
+		return w.Value == ")"
+	}
+	if w.Kind != WordLiteral || w.Raw != w.Value {
+		return false
+	}
+	switch w.Value {
+	case "}", "fi", "done", "esac":
+		return true
+	}
+	return false
+}
+
+// startsCommand reports whether the word after w is in command position
+func startsCommand(w Word) bool {
+	if w.Kind == WordOperator {
+		return w.Value != ")"
+	}
+	if w.Kind != WordLiteral || w.Raw != w.Value {
+		return false
+	}
+	switch w.Value {
+	case "{", "then", "do", "else", "!", "time":
+		return true
+	}
+	return false
+}
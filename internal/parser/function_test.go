@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFunctions(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		wantNames  []string
+		wantSpans  [][2]int
+		wantOpen   bool
+		bodySubstr string
+	}{
+		{
+			name:       "posix one-liner",
+			lines:      []string{`gcb() { git checkout -b "$1"; }`},
+			wantNames:  []string{"gcb"},
+			wantSpans:  [][2]int{{1, 1}},
+			bodySubstr: "git checkout -b",
+		},
+		{
+			name:       "ksh style keyword",
+			lines:      []string{"function mkcd {", `  mkdir -p "$1" && cd "$1"`, "}"},
+			wantNames:  []string{"mkcd"},
+			wantSpans:  [][2]int{{1, 3}},
+			bodySubstr: "mkdir -p",
+		},
+		{
+			name:      "keyword with parens",
+			lines:     []string{"function extract() {", "  tar xf \"$1\"", "}"},
+			wantNames: []string{"extract"},
+			wantSpans: [][2]int{{1, 3}},
+		},
+		{
+			name:      "brace on next line",
+			lines:     []string{"up ()", "{", "  cd ..", "}"},
+			wantNames: []string{"up"},
+			wantSpans: [][2]int{{1, 4}},
+		},
+		{
+			name:      "nested braces and parameter expansion",
+			lines:     []string{"f() {", "  if true; then { echo ${x:-}; }; fi", "}", "g() { :; }"},
+			wantNames: []string{"f", "g"},
+			wantSpans: [][2]int{{1, 3}, {4, 4}},
+		},
+		{
+			name:      "subshell body",
+			lines:     []string{"inrepo() (", "  cd \"$(git rev-parse --show-toplevel)\" && \"$@\"", ")"},
+			wantNames: []string{"inrepo"},
+			wantSpans: [][2]int{{1, 3}},
+		},
+		{
+			name:      "zsh closing brace without semicolon",
+			lines:     []string{"ll() { ls -l $@ }"},
+			wantNames: []string{"ll"},
+			wantSpans: [][2]int{{1, 1}},
+		},
+		{
+			name:      "closing brace as an argument",
+			lines:     []string{"f() {", "  echo }", "  alias infunc=1", "}"},
+			wantNames: []string{"f"},
+			wantSpans: [][2]int{{1, 4}},
+		},
+		{
+			name:      "two functions on one line",
+			lines:     []string{"a() { :; }; b() { :; }"},
+			wantNames: []string{"a", "b"},
+			wantSpans: [][2]int{{1, 1}, {1, 1}},
+		},
+		{
+			name:      "unterminated",
+			lines:     []string{"broken() {", "  echo"},
+			wantNames: nil,
+			wantOpen:  true,
+		},
+		{
+			name:  "not a function",
+			lines: []string{"echo foo()", "alias x='y()'", "arr=(a b)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewFunctionParser()
+
+			var names []string
+			var spans [][2]int
+			var bodies []string
+			for i, line := range tt.lines {
+				for _, fn := range parser.ParseLine(line, "/test/file", i+1) {
+					names = append(names, fn.Name)
+					spans = append(spans, [2]int{fn.Location.LineNum, fn.Location.EndLine})
+					bodies = append(bodies, fn.Body)
+				}
+			}
+
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Fatalf("ParseLine() names = %v, want %v", names, tt.wantNames)
+			}
+
+			for i := range spans {
+				if spans[i] != tt.wantSpans[i] {
+					t.Errorf("function %s span = %v, want %v", names[i], spans[i], tt.wantSpans[i])
+				}
+			}
+
+			if tt.bodySubstr != "" && !strings.Contains(bodies[0], tt.bodySubstr) {
+				t.Errorf("function %s body = %q, want it to contain %q", names[0], bodies[0], tt.bodySubstr)
+			}
+
+			if _, open := parser.Pending(); open != tt.wantOpen {
+				t.Errorf("Pending() = %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
@@ -0,0 +1,612 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// WordKind classifies the tokens produced by Tokenize
+type WordKind int
+
+const (
+	// WordLiteral is an ordinary shell word such as a command name or argument
+	WordLiteral WordKind = iota
+	// WordOperator is a control operator: ;, ;;, &, &&, |, ||, (, ) or a newline
+	WordOperator
+	// WordRedirect is a redirection operator such as >, >>, 2>&1 or <
+	WordRedirect
+)
+
+// Word is a single token of a shell command line
+type Word struct {
+	Kind  WordKind
+	Raw   string // Text exactly as written in the source, quotes included
+	Value string // Text after quote removal and escape processing
+}
+
+// Dialect selects the quoting rules used by the tokenizer
+type Dialect int
+
+const (
+	// DialectPOSIX follows bash and zsh quoting rules
+	DialectPOSIX Dialect = iota
+	// DialectFish follows fish quoting rules: backslash escapes inside single
+	// quotes, no $'...' strings, no backquotes and (...) command substitutions
+	DialectFish
+)
+
+// ErrUnterminated is returned by Tokenize when the input ends inside a quoted
+// string or substitution. The words read so far are still returned.
+var ErrUnterminated = errors.New("unterminated quote or substitution")
+
+// Tokenize splits shell source into words following POSIX and zsh quoting rules.
+// It understands single and double quotes, $'...' ANSI-C strings, backslash
+// escapes, line continuations and comments. Parameter expansions, command
+// substitutions and backquotes are kept verbatim in the word value since
+// their result is only known at runtime.
+func Tokenize(src string) ([]Word, error) {
+	return TokenizeDialect(src, DialectPOSIX)
+}
+
+// TokenizeDialect splits shell source into words using the quoting rules of dialect
+func TokenizeDialect(src string, dialect Dialect) ([]Word, error) {
+	t := &tokenizer{src: src, fish: dialect == DialectFish}
+	err := t.run()
+	return t.words, err
+}
+
+// SplitCommands splits a token stream into simple commands at control
+// operators. Redirections and their targets are dropped.
+func SplitCommands(words []Word) [][]Word {
+	var commands [][]Word
+	var current []Word
+
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		switch w.Kind {
+		case WordOperator:
+			if len(current) > 0 {
+				commands = append(commands, current)
+				current = nil
+			}
+		case WordRedirect:
+			// Skip the redirection target unless the operator already carries it
+			if !hasInlineTarget(w.Raw) && i+1 < len(words) && words[i+1].Kind == WordLiteral {
+				i++
+			}
+		default:
+			current = append(current, w)
+		}
+	}
+
+	if len(current) > 0 {
+		commands = append(commands, current)
+	}
+
+	return commands
+}
+
+// tokenizer holds the state of a single Tokenize call
+type tokenizer struct {
+	src   string
+	pos   int
+	words []Word
+	fish  bool // Use fish quoting rules
+}
+
+// run consumes the whole input
+func (t *tokenizer) run() error {
+	for t.pos < len(t.src) {
+		ch := t.src[t.pos]
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			t.pos++
+
+		case ch == '\\' && t.pos+1 < len(t.src) && t.src[t.pos+1] == '\n':
+			// Line continuation between words
+			t.pos += 2
+
+		case ch == '#':
+			// Comment runs until the end of the line
+			end := strings.IndexByte(t.src[t.pos:], '\n')
+			if end < 0 {
+				t.pos = len(t.src)
+			} else {
+				t.pos += end
+			}
+
+		case ch == '(' && t.fish:
+			// Command substitution starting a word
+			if err := t.readWord(); err != nil {
+				return err
+			}
+
+		case ch == '\n' || ch == ';' || ch == '|' || ch == '(' || ch == ')':
+			t.readOperator()
+
+		case ch == '&':
+			if strings.HasPrefix(t.src[t.pos:], "&>") {
+				t.readRedirect(t.pos)
+			} else {
+				t.readOperator()
+			}
+
+		case ch == '<' || ch == '>':
+			t.readRedirect(t.pos)
+
+		case ch >= '0' && ch <= '9' && t.isFdRedirect():
+			t.readRedirect(t.pos)
+
+		default:
+			if err := t.readWord(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isFdRedirect reports whether the digits at the current position are a file
+// descriptor prefix of a redirection, as in 2>/dev/null
+func (t *tokenizer) isFdRedirect() bool {
+	i := t.pos
+	for i < len(t.src) && t.src[i] >= '0' && t.src[i] <= '9' {
+		i++
+	}
+	return i < len(t.src) && (t.src[i] == '<' || t.src[i] == '>')
+}
+
+// readOperator reads a control operator
+func (t *tokenizer) readOperator() {
+	start := t.pos
+	rest := t.src[t.pos:]
+
+	switch {
+	case strings.HasPrefix(rest, ";;"), strings.HasPrefix(rest, "&&"),
+		strings.HasPrefix(rest, "||"), strings.HasPrefix(rest, "|&"):
+		t.pos += 2
+	default:
+		t.pos++
+	}
+
+	op := t.src[start:t.pos]
+	t.words = append(t.words, Word{Kind: WordOperator, Raw: op, Value: op})
+}
+
+// readRedirect reads a redirection operator, including a leading file
+// descriptor and a trailing &N or &- duplication target
+func (t *tokenizer) readRedirect(start int) {
+	for t.pos < len(t.src) && t.src[t.pos] >= '0' && t.src[t.pos] <= '9' {
+		t.pos++
+	}
+
+	rest := t.src[t.pos:]
+	for _, op := range []string{"&>>", "&>", "<<<", "<<-", "<<", ">>", ">&", "<&", ">|", "<>", ">", "<"} {
+		if strings.HasPrefix(rest, op) {
+			t.pos += len(op)
+			break
+		}
+	}
+
+	// Duplications like 2>&1 or >&- carry their target
+	if strings.HasSuffix(t.src[start:t.pos], "&") {
+		for t.pos < len(t.src) && (t.src[t.pos] == '-' || (t.src[t.pos] >= '0' && t.src[t.pos] <= '9')) {
+			t.pos++
+		}
+	}
+
+	op := t.src[start:t.pos]
+	t.words = append(t.words, Word{Kind: WordRedirect, Raw: op, Value: op})
+}
+
+// hasInlineTarget reports whether a redirection operator already names its
+// target, as in 2>&1 or >&-
+func hasInlineTarget(op string) bool {
+	if !strings.Contains(op, ">&") && !strings.Contains(op, "<&") {
+		return false
+	}
+	last := op[len(op)-1]
+	return last == '-' || (last >= '0' && last <= '9')
+}
+
+// readWord reads a single word, processing quotes and escapes
+func (t *tokenizer) readWord() error {
+	start := t.pos
+	var value strings.Builder
+
+	finish := func() {
+		t.words = append(t.words, Word{Kind: WordLiteral, Raw: t.src[start:t.pos], Value: value.String()})
+	}
+
+	for t.pos < len(t.src) {
+		ch := t.src[t.pos]
+
+		switch ch {
+		case ' ', '\t', '\r', '\n', ';', '&', '|', '<', '>', ')':
+			finish()
+			return nil
+
+		case '(':
+			// An empty pair ends the word (function definition); any other
+			// group is a zsh glob qualifier, an extglob pattern or a fish
+			// command substitution and stays in the word
+			if !t.fish && strings.HasPrefix(t.src[t.pos:], "()") {
+				finish()
+				return nil
+			}
+			end := skipBalanced(t.src, t.pos+1, '(', ')')
+			if end < 0 {
+				value.WriteString(t.src[t.pos:])
+				t.pos = len(t.src)
+				finish()
+				return ErrUnterminated
+			}
+			value.WriteString(t.src[t.pos:end])
+			t.pos = end
+
+		case '\\':
+			if t.pos+1 >= len(t.src) {
+				value.WriteByte('\\')
+				t.pos++
+				continue
+			}
+			if t.src[t.pos+1] != '\n' {
+				value.WriteByte(t.src[t.pos+1])
+			}
+			t.pos += 2
+
+		case '\'':
+			if t.fish {
+				if err := t.readFishSingleQuoted(&value); err != nil {
+					finish()
+					return err
+				}
+				continue
+			}
+			end := strings.IndexByte(t.src[t.pos+1:], '\'')
+			if end < 0 {
+				value.WriteString(t.src[t.pos+1:])
+				t.pos = len(t.src)
+				finish()
+				return ErrUnterminated
+			}
+			value.WriteString(t.src[t.pos+1 : t.pos+1+end])
+			t.pos += end + 2
+
+		case '"':
+			if err := t.readDoubleQuoted(&value); err != nil {
+				finish()
+				return err
+			}
+
+		case '$':
+			if err := t.readDollar(&value); err != nil {
+				finish()
+				return err
+			}
+
+		case '`':
+			if t.fish {
+				value.WriteByte(ch)
+				t.pos++
+				continue
+			}
+			end := skipBackquote(t.src, t.pos+1)
+			if end < 0 {
+				value.WriteString(t.src[t.pos:])
+				t.pos = len(t.src)
+				finish()
+				return ErrUnterminated
+			}
+			value.WriteString(t.src[t.pos:end])
+			t.pos = end
+
+		default:
+			value.WriteByte(ch)
+			t.pos++
+		}
+	}
+
+	finish()
+	return nil
+}
+
+// readFishSingleQuoted reads a fish '...' string, where \' and \\ are escapes
+func (t *tokenizer) readFishSingleQuoted(value *strings.Builder) error {
+	t.pos++ // Opening quote
+
+	for t.pos < len(t.src) {
+		ch := t.src[t.pos]
+
+		switch {
+		case ch == '\'':
+			t.pos++
+			return nil
+		case ch == '\\' && t.pos+1 < len(t.src) && (t.src[t.pos+1] == '\'' || t.src[t.pos+1] == '\\'):
+			value.WriteByte(t.src[t.pos+1])
+			t.pos += 2
+		default:
+			value.WriteByte(ch)
+			t.pos++
+		}
+	}
+
+	return ErrUnterminated
+}
+
+// readDoubleQuoted reads a "..." string starting at the opening quote
+func (t *tokenizer) readDoubleQuoted(value *strings.Builder) error {
+	t.pos++ // Opening quote
+
+	for t.pos < len(t.src) {
+		ch := t.src[t.pos]
+
+		switch ch {
+		case '"':
+			t.pos++
+			return nil
+
+		case '\\':
+			if t.pos+1 >= len(t.src) {
+				value.WriteByte('\\')
+				t.pos++
+				continue
+			}
+			// Inside double quotes a backslash only escapes $ ` " \ and newline
+			// (fish does not treat the backquote specially)
+			switch next := t.src[t.pos+1]; {
+			case next == '$', next == '"', next == '\\', next == '`' && !t.fish:
+				value.WriteByte(next)
+			case next == '\n':
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(next)
+			}
+			t.pos += 2
+
+		case '$':
+			if strings.HasPrefix(t.src[t.pos:], "$'") {
+				// No ANSI-C quoting inside double quotes
+				value.WriteByte('$')
+				t.pos++
+				continue
+			}
+			if err := t.readDollar(value); err != nil {
+				return err
+			}
+
+		case '`':
+			if t.fish {
+				value.WriteByte(ch)
+				t.pos++
+				continue
+			}
+			end := skipBackquote(t.src, t.pos+1)
+			if end < 0 {
+				value.WriteString(t.src[t.pos:])
+				t.pos = len(t.src)
+				return ErrUnterminated
+			}
+			value.WriteString(t.src[t.pos:end])
+			t.pos = end
+
+		default:
+			value.WriteByte(ch)
+			t.pos++
+		}
+	}
+
+	return ErrUnterminated
+}
+
+// readDollar reads an expansion starting at a $ sign. ANSI-C strings are
+// decoded; substitutions and parameter expansions are kept verbatim.
+func (t *tokenizer) readDollar(value *strings.Builder) error {
+	rest := t.src[t.pos:]
+
+	switch {
+	case strings.HasPrefix(rest, "$'") && !t.fish:
+		decoded, end := decodeANSIC(t.src, t.pos+2)
+		value.WriteString(decoded)
+		if end < 0 {
+			t.pos = len(t.src)
+			return ErrUnterminated
+		}
+		t.pos = end
+
+	case strings.HasPrefix(rest, "$("), strings.HasPrefix(rest, "${"):
+		open, close := byte('('), byte(')')
+		if rest[1] == '{' {
+			open, close = '{', '}'
+		}
+		end := skipBalanced(t.src, t.pos+2, open, close)
+		if end < 0 {
+			value.WriteString(rest)
+			t.pos = len(t.src)
+			return ErrUnterminated
+		}
+		value.WriteString(t.src[t.pos:end])
+		t.pos = end
+
+	default:
+		value.WriteByte('$')
+		t.pos++
+	}
+
+	return nil
+}
+
+// skipBalanced returns the index just past the delimiter that closes a group
+// whose opening delimiter sits right before start, or -1 if the input ends first
+func skipBalanced(src string, start int, open, close byte) int {
+	depth := 1
+
+	for i := start; i < len(src); i++ {
+		switch ch := src[i]; ch {
+		case '\\':
+			i++
+		case '#':
+			// A comment runs to the end of the line, quotes and all
+			if (i == start && open == '(') || (i > start && strings.IndexByte(" \t\n;&|()<>", src[i-1]) >= 0) {
+				end := strings.IndexByte(src[i:], '\n')
+				if end < 0 {
+					return -1
+				}
+				i += end
+			}
+		case '\'':
+			end := strings.IndexByte(src[i+1:], '\'')
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '"':
+			end := skipDoubleQuoted(src, i+1)
+			if end < 0 {
+				return -1
+			}
+			i = end - 1
+		case '`':
+			end := skipBackquote(src, i+1)
+			if end < 0 {
+				return -1
+			}
+			i = end - 1
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
+
+// skipDoubleQuoted returns the index just past the closing double quote
+func skipDoubleQuoted(src string, start int) int {
+	for i := start; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		case '`':
+			end := skipBackquote(src, i+1)
+			if end < 0 {
+				return -1
+			}
+			i = end - 1
+		case '$':
+			if i+1 < len(src) && (src[i+1] == '(' || src[i+1] == '{') {
+				open, close := byte('('), byte(')')
+				if src[i+1] == '{' {
+					open, close = '{', '}'
+				}
+				end := skipBalanced(src, i+2, open, close)
+				if end < 0 {
+					return -1
+				}
+				i = end - 1
+			}
+		}
+	}
+	return -1
+}
+
+// skipBackquote returns the index just past the closing backquote
+func skipBackquote(src string, start int) int {
+	for i := start; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '`':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// decodeANSIC decodes the body of a $'...' string starting at start.
+// It returns the decoded text and the index just past the closing quote,
+// or -1 if the string is not terminated.
+func decodeANSIC(src string, start int) (string, int) {
+	var out strings.Builder
+
+	for i := start; i < len(src); i++ {
+		ch := src[i]
+
+		if ch == '\'' {
+			return out.String(), i + 1
+		}
+
+		if ch != '\\' || i+1 >= len(src) {
+			out.WriteByte(ch)
+			continue
+		}
+
+		i++
+		switch esc := src[i]; esc {
+		case 'a':
+			out.WriteByte('\a')
+		case 'b':
+			out.WriteByte('\b')
+		case 'e', 'E':
+			out.WriteByte(0x1b)
+		case 'f':
+			out.WriteByte('\f')
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case 'v':
+			out.WriteByte('\v')
+		case '\\', '\'', '"', '?':
+			out.WriteByte(esc)
+		case 'x', 'u', 'U':
+			maxDigits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[esc]
+			j := i + 1
+			for j < len(src) && j-i-1 < maxDigits && isHexDigit(src[j]) {
+				j++
+			}
+			if j == i+1 {
+				out.WriteByte('\\')
+				out.WriteByte(esc)
+				continue
+			}
+			n, _ := strconv.ParseUint(src[i+1:j], 16, 32)
+			if esc == 'x' {
+				out.WriteByte(byte(n))
+			} else {
+				out.WriteRune(rune(n))
+			}
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(src) && j-i < 3 && src[j] >= '0' && src[j] <= '7' {
+				j++
+			}
+			// Values above \377 keep their low 8 bits, like the shell
+			n, _ := strconv.ParseUint(src[i:j], 8, 16)
+			out.WriteByte(byte(n & 0xff))
+			i = j - 1
+		default:
+			out.WriteByte('\\')
+			out.WriteByte(esc)
+		}
+	}
+
+	return out.String(), -1
+}
+
+// isHexDigit reports whether ch is a hexadecimal digit
+func isHexDigit(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		dialect    Dialect
+		wantValues []string
+		wantErr    bool
+	}{
+		{
+			name:       "plain words",
+			src:        "echo  hello\tworld",
+			wantValues: []string{"echo", "hello", "world"},
+		},
+		{
+			name:       "single quotes",
+			src:        `echo 'a b' c`,
+			wantValues: []string{"echo", "a b", "c"},
+		},
+		{
+			name:       "escaped single quote idiom",
+			src:        `'it'\''s'`,
+			wantValues: []string{"it's"},
+		},
+		{
+			name:       "double quotes with escapes",
+			src:        `"a \"b\" \$c \d"`,
+			wantValues: []string{`a "b" $c \d`},
+		},
+		{
+			name:       "ansi-c escapes",
+			src:        `$'a\tb\x41\101é\''`,
+			wantValues: []string{"a\tbAAé'"},
+		},
+		{
+			name:       "octal escape above 0377",
+			src:        `$'\777\101'`,
+			wantValues: []string{"\xffA"},
+		},
+		{
+			name:       "command substitution kept verbatim",
+			src:        `"$(dirname "${BASH_SOURCE[0]}")/x.sh"`,
+			wantValues: []string{`$(dirname "${BASH_SOURCE[0]}")/x.sh`},
+		},
+		{
+			name:       "parameter expansion kept verbatim",
+			src:        `${DOTFILES:-"$HOME/.dotfiles"}/zsh`,
+			wantValues: []string{`${DOTFILES:-"$HOME/.dotfiles"}/zsh`},
+		},
+		{
+			name:       "comment in a substitution",
+			src:        "$(ls # it's\n  -a)",
+			wantValues: []string{"$(ls # it's\n  -a)"},
+		},
+		{
+			name:       "length expansion is not a comment",
+			src:        "${#PATH}x",
+			wantValues: []string{"${#PATH}x"},
+		},
+		{
+			name:       "operators",
+			src:        "a && b || c; d | e &",
+			wantValues: []string{"a", "&&", "b", "||", "c", ";", "d", "|", "e", "&"},
+		},
+		{
+			name:       "redirections",
+			src:        "command -v foo >/dev/null 2>&1",
+			wantValues: []string{"command", "-v", "foo", ">", "/dev/null", "2>&1"},
+		},
+		{
+			name:       "comment",
+			src:        "echo a # b c",
+			wantValues: []string{"echo", "a"},
+		},
+		{
+			name:       "hash inside word",
+			src:        "echo a#b",
+			wantValues: []string{"echo", "a#b"},
+		},
+		{
+			name:       "line continuation",
+			src:        "echo a\\\nb c",
+			wantValues: []string{"echo", "ab", "c"},
+		},
+		{
+			name:       "zsh glob qualifier",
+			src:        "for f in $ZDOTDIR/conf.d/*(N)",
+			wantValues: []string{"for", "f", "in", "$ZDOTDIR/conf.d/*(N)"},
+		},
+		{
+			name:       "function definition",
+			src:        "mkcd() {",
+			wantValues: []string{"mkcd", "(", ")", "{"},
+		},
+		{
+			name:       "fish single quote escapes",
+			src:        `alias it 'it\'s \\ \n'`,
+			dialect:    DialectFish,
+			wantValues: []string{"alias", "it", `it's \ \n`},
+		},
+		{
+			name:       "fish command substitution",
+			src:        `set -l d (dirname (status filename))`,
+			dialect:    DialectFish,
+			wantValues: []string{"set", "-l", "d", "(dirname (status filename))"},
+		},
+		{
+			name:       "fish has no ansi-c strings",
+			src:        `echo $'a'`,
+			dialect:    DialectFish,
+			wantValues: []string{"echo", "$a"},
+		},
+		{
+			name:       "unterminated quote",
+			src:        "alias x='abc",
+			wantValues: []string{"alias", "x=abc"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := TokenizeDialect(tt.src, tt.dialect)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Tokenize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(words) != len(tt.wantValues) {
+				t.Fatalf("Tokenize() returned %d words, want %d: %+v", len(words), len(tt.wantValues), words)
+			}
+
+			for i, w := range words {
+				if w.Value != tt.wantValues[i] {
+					t.Errorf("Tokenize()[%d].Value = %q, want %q", i, w.Value, tt.wantValues[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitCommands(t *testing.T) {
+	words, _ := Tokenize("[ -f x ] && alias a=b >/dev/null 2>&1; alias c=d")
+	commands := SplitCommands(words)
+
+	if len(commands) != 3 {
+		t.Fatalf("SplitCommands() returned %d commands, want 3", len(commands))
+	}
+
+	if got := len(commands[1]); got != 2 {
+		t.Errorf("SplitCommands()[1] has %d words, want 2 (redirections dropped)", got)
+	}
+}
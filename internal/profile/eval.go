@@ -0,0 +1,419 @@
+package profile
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+// Result is the outcome of evaluating a condition
+type Result int
+
+const (
+	Unknown Result = iota // The facts don't decide it
+	True
+	False
+)
+
+// not negates a result; an unknown result stays unknown
+func (r Result) not() Result {
+	switch r {
+	case True:
+		return False
+	case False:
+		return True
+	default:
+		return Unknown
+	}
+}
+
+// and combines results like the shell's &&, false winning over unknown
+func and(a, b Result) Result {
+	switch {
+	case a == False || b == False:
+		return False
+	case a == True && b == True:
+		return True
+	default:
+		return Unknown
+	}
+}
+
+// or combines results like the shell's ||, true winning over unknown
+func or(a, b Result) Result {
+	return and(a.not(), b.not()).not()
+}
+
+// ExpandFunc expands a path as written in a test to an absolute path, the
+// way the shell would where the test runs
+type ExpandFunc func(path string) (string, bool)
+
+// commandsPattern matches zsh's check for an installed command: $+commands[name]
+var commandsPattern = regexp.MustCompile(`^\$\+commands\[([^\]]+)\]$`)
+
+// Evaluator decides conditions from the facts of a target system. It
+// understands a useful subset of shell tests:
+//
+//	[ -f path ], [[ -d path ]], test -r path
+//	[[ $OSTYPE == darwin* ]], [ "$(uname)" = Linux ], case $(uname -s) in ...
+//	command -v name, type name, hash name, (( $+commands[name] ))
+//	[ "$TERM_PROGRAM" = iTerm.app ]
+//
+// joined with !, &&, || and -a/-o. Anything else is unknown.
+type Evaluator struct {
+	facts Facts
+}
+
+// NewEvaluator creates an evaluator answering from facts
+func NewEvaluator(facts Facts) *Evaluator {
+	return &Evaluator{facts: facts}
+}
+
+// Evaluate returns whether all conditions hold, written in dialect
+func (e *Evaluator) Evaluate(conditions []model.Condition, dialect parser.Dialect, expand ExpandFunc) Result {
+	result := True
+	for _, c := range conditions {
+		if c.Function {
+			// Whether a function gets called isn't a fact of the system
+			result = and(result, Unknown)
+			continue
+		}
+		r := e.list(c.Text, dialect, expand)
+		if c.Negated {
+			r = r.not()
+		}
+		result = and(result, r)
+	}
+	return result
+}
+
+// list evaluates an and-or list left to right, like the shell
+func (e *Evaluator) list(text string, dialect parser.Dialect, expand ExpandFunc) Result {
+	words, err := parser.TokenizeDialect(text, dialect)
+	if err != nil || len(words) == 0 {
+		return Unknown
+	}
+	if words[0].Kind == parser.WordLiteral && words[0].Value == "case" {
+		return e.caseTest(words)
+	}
+
+	result, op := Unknown, ""
+	var current []parser.Word
+	apply := func() {
+		r := e.command(current, expand)
+		switch op {
+		case "":
+			result = r
+		case "&&":
+			result = and(result, r)
+		case "||":
+			result = or(result, r)
+		}
+		current = nil
+	}
+
+	inTest := false // Inside [[ ]], where && and || are part of the test
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case w.Kind == parser.WordLiteral && (w.Value == "[[" || w.Value == "]]"):
+			inTest = w.Value == "[["
+			current = append(current, w)
+		case w.Kind == parser.WordOperator && (w.Value == "&&" || w.Value == "||") && !inTest:
+			apply()
+			op = w.Value
+		case w.Kind == parser.WordOperator && w.Value == "(" && i+1 < len(words) && words[i+1].Value == "(":
+			// (( $+commands[name] ))
+			end := i + 2
+			for end < len(words) && words[end].Kind != parser.WordOperator {
+				end++
+			}
+			current = append(current, parser.Word{Kind: parser.WordLiteral, Raw: "((", Value: "(("})
+			current = append(current, words[i+2:end]...)
+			i = end + 1
+		case w.Kind == parser.WordOperator && !inTest:
+			return Unknown // Pipelines and subshells
+		default:
+			current = append(current, w)
+		}
+	}
+	apply()
+
+	return result
+}
+
+// command evaluates a simple command used as a test
+func (e *Evaluator) command(words []parser.Word, expand ExpandFunc) Result {
+	if len(words) == 0 {
+		return Unknown
+	}
+
+	args := values(words[1:])
+	switch words[0].Value {
+	case "!", "not":
+		return e.command(words[1:], expand).not()
+	case "[":
+		if len(args) == 0 || args[len(args)-1] != "]" {
+			return Unknown
+		}
+		return e.test(words[1:len(words)-1], false, expand)
+	case "[[":
+		if len(args) == 0 || args[len(args)-1] != "]]" {
+			return Unknown
+		}
+		return e.test(words[1:len(words)-1], true, expand)
+	case "test":
+		return e.test(words[1:], false, expand)
+	case "((":
+		if len(args) == 1 {
+			if m := commandsPattern.FindStringSubmatch(args[0]); m != nil {
+				return e.hasCommand(m[1])
+			}
+		}
+	case "command":
+		// command -v name
+		if len(args) == 2 && (args[0] == "-v" || args[0] == "-V") {
+			return e.hasCommand(args[1])
+		}
+	case "type", "hash":
+		// type name, type -q name (fish), type -p name, hash name
+		names := make([]string, 0, len(args))
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") {
+				names = append(names, arg)
+			}
+		}
+		if len(names) == 1 {
+			return e.hasCommand(names[0])
+		}
+	}
+
+	return Unknown
+}
+
+// test evaluates the expression of [ ], [[ ]] or test. extended selects
+// [[ ]] rules, where == and != match patterns and =~ a regular expression.
+func (e *Evaluator) test(words []parser.Word, extended bool, expand ExpandFunc) Result {
+	// Split at -a/-o, or &&/|| inside [[ ]], and combine left to right
+	for i := len(words) - 1; i > 0; i-- {
+		op := words[i].Value
+		isAnd := op == "-a" && !extended || op == "&&" && words[i].Kind == parser.WordOperator
+		isOr := op == "-o" && !extended || op == "||" && words[i].Kind == parser.WordOperator
+		if !isAnd && !isOr || i == len(words)-1 || isUnary(words[i-1].Value) {
+			continue
+		}
+		left, right := e.test(words[:i], extended, expand), e.test(words[i+1:], extended, expand)
+		if isAnd {
+			return and(left, right)
+		}
+		return or(left, right)
+	}
+
+	if len(words) > 0 && words[0].Value == "!" {
+		return e.test(words[1:], extended, expand).not()
+	}
+
+	switch len(words) {
+	case 1:
+		// [ string ] is true when the string isn't empty
+		value, ok := e.value(words[0])
+		return known(value != "", ok)
+	case 2:
+		return e.unary(words[0].Value, words[1], expand)
+	case 3:
+		return e.binary(words[0], words[1].Value, words[2], extended)
+	}
+	return Unknown
+}
+
+// isUnary reports whether op is a unary test operator
+func isUnary(op string) bool {
+	switch op {
+	case "-f", "-d", "-r", "-e", "-n", "-z":
+		return true
+	}
+	return false
+}
+
+// unary evaluates a unary test
+func (e *Evaluator) unary(op string, operand parser.Word, expand ExpandFunc) Result {
+	switch op {
+	case "-n", "-z":
+		value, ok := e.value(operand)
+		return known((value == "") == (op == "-z"), ok)
+	case "-f", "-d", "-r", "-e":
+	default:
+		return Unknown
+	}
+
+	path, ok := expand(operand.Raw)
+	if !ok {
+		return Unknown
+	}
+	kind, ok := e.facts.Stat(path)
+	if !ok {
+		return Unknown
+	}
+
+	switch op {
+	case "-e":
+		return known(kind != PathMissing, true)
+	case "-r":
+		return known(kind == PathFile || kind == PathDir, true)
+	}
+	if kind == PathUnreadable {
+		return Unknown // Exists, but of an unknown type
+	}
+	if op == "-f" {
+		return known(kind == PathFile, true)
+	}
+	return known(kind == PathDir, true)
+}
+
+// binary evaluates a string comparison
+func (e *Evaluator) binary(left parser.Word, op string, right parser.Word, extended bool) Result {
+	value, ok := e.value(left)
+	if !ok {
+		return Unknown
+	}
+	pattern, ok := e.value(right)
+	if !ok {
+		return Unknown
+	}
+
+	switch op {
+	case "=", "==":
+		return known(compare(value, pattern, right, extended), true)
+	case "!=":
+		return known(!compare(value, pattern, right, extended), true)
+	case "=~":
+		if !extended {
+			return Unknown
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Unknown
+		}
+		return known(re.MatchString(value), true)
+	}
+	return Unknown
+}
+
+// compare reports whether value equals the right operand, or matches it as a
+// pattern in [[ ]] if it isn't quoted
+func compare(value, pattern string, right parser.Word, extended bool) bool {
+	if extended && right.Raw == right.Value {
+		return matchPattern(pattern, value)
+	}
+	return value == pattern
+}
+
+// caseTest evaluates case word in pattern|pattern, as the condition tracker
+// records case branches
+func (e *Evaluator) caseTest(words []parser.Word) Result {
+	if len(words) < 4 || words[2].Value != "in" {
+		return Unknown
+	}
+	value, ok := e.value(words[1])
+	if !ok {
+		return Unknown
+	}
+
+	for _, w := range words[3:] {
+		if w.Kind == parser.WordLiteral && matchPattern(w.Value, value) {
+			return True
+		}
+	}
+	return False
+}
+
+// value returns the value of a test operand: a literal, $OSTYPE, the
+// output of uname or an environment variable the facts know
+func (e *Evaluator) value(w parser.Word) (string, bool) {
+	v := w.Value
+	if !strings.ContainsAny(v, "$`(") {
+		return v, true
+	}
+
+	// $(uname), `uname -s` or fish's (uname)
+	for _, prefix := range [][2]string{{"$(", ")"}, {"`", "`"}, {"(", ")"}} {
+		if strings.HasPrefix(v, prefix[0]) && strings.HasSuffix(v, prefix[1]) && len(v) > len(prefix[0]) {
+			command := strings.Fields(v[len(prefix[0]) : len(v)-len(prefix[1])])
+			if len(command) == 1 && command[0] == "uname" || len(command) == 2 && command[0] == "uname" && command[1] == "-s" {
+				return e.facts.Uname()
+			}
+			return "", false
+		}
+	}
+
+	// $NAME or ${NAME}
+	name := strings.TrimPrefix(v, "$")
+	if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") {
+		name = name[1 : len(name)-1]
+	}
+	if name == v || !isName(name) {
+		return "", false
+	}
+	if name == "OSTYPE" {
+		return e.facts.OSType()
+	}
+	return e.facts.Getenv(name)
+}
+
+// hasCommand checks whether a command is installed
+func (e *Evaluator) hasCommand(name string) Result {
+	installed, ok := e.facts.HasCommand(name)
+	return known(installed, ok)
+}
+
+// ListedCommand returns whether the target profile file lists name as
+// installed, and false as its last result if it doesn't list it. The local
+// system is never asked, since its PATH isn't the one the scanned files build.
+func (e *Evaluator) ListedCommand(name string) (bool, bool) {
+	p, ok := e.facts.(*Profile)
+	if !ok {
+		return false, false
+	}
+	return p.HasCommand(name)
+}
+
+// known converts a decided boolean to a result
+func known(value bool, ok bool) Result {
+	switch {
+	case !ok:
+		return Unknown
+	case value:
+		return True
+	default:
+		return False
+	}
+}
+
+// matchPattern matches a shell glob pattern against value
+func matchPattern(pattern, value string) bool {
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// values returns the values of words
+func values(words []parser.Word) []string {
+	result := make([]string, len(words))
+	for i, w := range words {
+		result[i] = w.Value
+	}
+	return result
+}
+
+// isName reports whether s is a variable name
+func isName(s string) bool {
+	for i, ch := range s {
+		isLetter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		if !isLetter && (i == 0 || ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return s != ""
+}
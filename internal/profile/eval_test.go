@@ -0,0 +1,109 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+func TestEvaluate(t *testing.T) {
+	linux := &Profile{
+		OS:       "linux",
+		Commands: map[string]bool{"kubectl": true, "podman": false},
+		Paths:    map[string]PathKind{"/home/me/.work": PathFile, "/home/me/bin": PathDir, "/opt/homebrew": PathMissing},
+		Env:      map[string]string{"TERM_PROGRAM": "vscode"},
+	}
+	// Paths are relative to the home directory; variables are unknown
+	expand := func(path string) (string, bool) {
+		path = strings.Trim(path, `"'`)
+		if strings.Contains(path, "$") {
+			return "", false
+		}
+		if filepath.IsAbs(path) {
+			return path, true
+		}
+		return filepath.Join("/home/me", path), true
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		negated bool
+		dialect parser.Dialect
+		want    Result
+	}{
+		{name: "file exists", text: "[ -f .work ]", want: True},
+		{name: "not a directory", text: "[[ -d .work ]]", want: False},
+		{name: "readable directory", text: "test -r bin", want: True},
+		{name: "missing", text: "[ -e /opt/homebrew ]", want: False},
+		{name: "unlisted path", text: "[ -f .other ]", want: Unknown},
+		{name: "unresolved path", text: `[ -f "$UNKNOWN/x" ]`, want: Unknown},
+		{name: "ostype pattern", text: "[[ $OSTYPE == linux* ]]", want: True},
+		{name: "ostype quoted pattern", text: `[[ "$OSTYPE" == "linux*" ]]`, want: False},
+		{name: "ostype regexp", text: "[[ $OSTYPE =~ ^darwin ]]", want: False},
+		{name: "uname", text: `[ "$(uname)" = Linux ]`, want: True},
+		{name: "uname -s differs", text: "[ `uname -s` != Darwin ]", want: True},
+		{name: "case uname", text: `case "$(uname)" in Darwin|FreeBSD`, want: False},
+		{name: "case ostype", text: "case $OSTYPE in darwin*|linux*", want: True},
+		{name: "fish switch", text: "case (uname) in Linux", dialect: parser.DialectFish, want: True},
+		{name: "command -v", text: "command -v kubectl", want: True},
+		{name: "type", text: "type podman", want: False},
+		{name: "fish type -q", text: "type -q kubectl", dialect: parser.DialectFish, want: True},
+		{name: "hash unlisted", text: "hash docker", want: Unknown},
+		{name: "zsh commands", text: "(( $+commands[podman] ))", want: False},
+		{name: "term program", text: `[ "$TERM_PROGRAM" = iTerm.app ]`, want: False},
+		{name: "negated", text: "command -v podman", negated: true, want: True},
+		{name: "bang", text: "! [ -d /opt/homebrew ]", want: True},
+		{name: "and list", text: "command -v kubectl && [ -f .work ]", want: True},
+		{name: "false wins over unknown", text: "hash docker && type podman", want: False},
+		{name: "or list", text: "command -v podman || command -v kubectl", want: True},
+		{name: "and inside [[ ]]", text: "[[ -f .work && $OSTYPE == darwin* ]]", want: False},
+		{name: "-o", text: `[ -d /opt/homebrew -o -n "$TERM_PROGRAM" ]`, want: True},
+		{name: "unsupported", text: "[ $(date +%H) -lt 12 ]", want: Unknown},
+	}
+
+	evaluator := NewEvaluator(linux)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := []model.Condition{{Text: tt.text, Negated: tt.negated}}
+			if got := evaluator.Evaluate(conditions, tt.dialect, expand); got != tt.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mac.yaml")
+	content := "os: macOS\ncommands:\n  brew: true\npaths:\n  ~/.work: file\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if uname, _ := p.Uname(); uname != "Darwin" {
+		t.Errorf("Uname() = %q, want Darwin", uname)
+	}
+	if ostype, _ := p.OSType(); ostype != "darwin" {
+		t.Errorf("OSType() = %q, want darwin", ostype)
+	}
+	homeDir, _ := os.UserHomeDir()
+	if kind, ok := p.Stat(filepath.Join(homeDir, ".work")); !ok || kind != PathFile {
+		t.Errorf("Stat(~/.work) = %q, %v, want file", kind, ok)
+	}
+
+	if err := os.WriteFile(path, []byte("paths:\n  /x: symlink\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted an invalid path kind")
+	}
+}
@@ -0,0 +1,199 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Facts answers the questions conditions ask about the system the shell
+// runs on. Each method reports false as its last result when the answer is
+// unknown, which leaves the condition undecided.
+type Facts interface {
+	OSType() (string, bool)                     // $OSTYPE: darwin23.0, linux-gnu
+	Uname() (string, bool)                      // uname -s: Darwin, Linux
+	HasCommand(name string) (bool, bool)        // Installed on PATH
+	Stat(path string) (PathKind, bool)          // What an absolute path is
+	Getenv(name string) (value string, ok bool) // Environment variable, empty if unset
+}
+
+// PathKind is what a path names
+type PathKind string
+
+const (
+	PathMissing    PathKind = "missing"
+	PathFile       PathKind = "file"
+	PathDir        PathKind = "dir"
+	PathUnreadable PathKind = "unreadable" // Exists but can't be read
+)
+
+// osNames maps uname -s output, in lower case, to its name and the
+// $OSTYPE bash and zsh report on that system
+var osNames = map[string][2]string{
+	"darwin":  {"Darwin", "darwin"},
+	"macos":   {"Darwin", "darwin"},
+	"linux":   {"Linux", "linux-gnu"},
+	"freebsd": {"FreeBSD", "freebsd"},
+	"openbsd": {"OpenBSD", "openbsd"},
+	"netbsd":  {"NetBSD", "netbsd"},
+	"windows": {"MINGW64_NT", "msys"},
+}
+
+// localVariables are the variables Local reads from the environment. Others
+// are usually set by the scanned files themselves, so the environment of
+// falias says nothing about them.
+var localVariables = map[string]bool{
+	"TERM_PROGRAM": true,
+	"TERM":         true,
+	"COLORTERM":    true,
+}
+
+// Local answers from the machine falias runs on
+type Local struct{}
+
+// OSType returns $OSTYPE if exported, or the value the shell sets on this OS
+func (Local) OSType() (string, bool) {
+	if ostype := os.Getenv("OSTYPE"); ostype != "" {
+		return ostype, true
+	}
+	name, ok := osNames[runtime.GOOS]
+	return name[1], ok
+}
+
+// Uname returns the kernel name uname -s prints on this OS
+func (Local) Uname() (string, bool) {
+	name, ok := osNames[runtime.GOOS]
+	return name[0], ok
+}
+
+// HasCommand looks name up on PATH
+func (Local) HasCommand(name string) (bool, bool) {
+	_, err := exec.LookPath(name)
+	return err == nil, true
+}
+
+// Stat checks the local filesystem
+func (Local) Stat(path string) (PathKind, bool) {
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		return PathMissing, true
+	case err != nil:
+		return PathUnreadable, true
+	case info.IsDir():
+		return PathDir, true
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return PathUnreadable, true
+	}
+	file.Close()
+	return PathFile, true
+}
+
+// Getenv reads the terminal variables from the environment
+func (Local) Getenv(name string) (string, bool) {
+	if !localVariables[name] {
+		return "", false
+	}
+	return os.Getenv(name), true
+}
+
+// Profile describes a target system in a YAML file, such as:
+//
+//	os: linux
+//	commands:
+//	  kubectl: true
+//	  podman: false
+//	paths:
+//	  ~/.work: file
+//	  /opt/homebrew: missing
+//	env:
+//	  TERM_PROGRAM: iTerm.app
+//
+// Anything the profile doesn't list is unknown.
+type Profile struct {
+	OS       string              `yaml:"os"`     // uname -s, case-insensitive; macos is an alias of darwin
+	OSTYPE   string              `yaml:"ostype"` // $OSTYPE, derived from os if empty
+	Commands map[string]bool     `yaml:"commands"`
+	Paths    map[string]PathKind `yaml:"paths"` // Keys may start with ~/
+	Env      map[string]string   `yaml:"env"`   // An empty value means unset
+}
+
+// Load reads a profile file
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	var p Profile
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+
+	if p.OS != "" {
+		if _, ok := osNames[strings.ToLower(p.OS)]; !ok {
+			return nil, fmt.Errorf("unknown os in profile: %s", p.OS)
+		}
+	}
+	for path, kind := range p.Paths {
+		switch kind {
+		case PathMissing, PathFile, PathDir, PathUnreadable:
+		default:
+			return nil, fmt.Errorf("invalid kind for path %s in profile: %s (want file, dir, missing or unreadable)", path, kind)
+		}
+	}
+
+	// Paths are compared once expanded
+	homeDir, _ := os.UserHomeDir()
+	paths := make(map[string]PathKind, len(p.Paths))
+	for path, kind := range p.Paths {
+		if strings.HasPrefix(path, "~/") {
+			path = filepath.Join(homeDir, path[2:])
+		}
+		paths[filepath.Clean(path)] = kind
+	}
+	p.Paths = paths
+
+	return &p, nil
+}
+
+// OSType returns the profile's $OSTYPE
+func (p *Profile) OSType() (string, bool) {
+	if p.OSTYPE != "" {
+		return p.OSTYPE, true
+	}
+	name, ok := osNames[strings.ToLower(p.OS)]
+	return name[1], ok
+}
+
+// Uname returns the kernel name of the profile's OS
+func (p *Profile) Uname() (string, bool) {
+	name, ok := osNames[strings.ToLower(p.OS)]
+	return name[0], ok
+}
+
+// HasCommand reports whether the profile lists name as installed
+func (p *Profile) HasCommand(name string) (bool, bool) {
+	installed, ok := p.Commands[name]
+	return installed, ok
+}
+
+// Stat returns the kind the profile lists for path
+func (p *Profile) Stat(path string) (PathKind, bool) {
+	kind, ok := p.Paths[filepath.Clean(path)]
+	return kind, ok
+}
+
+// Getenv returns the value the profile lists for a variable
+func (p *Profile) Getenv(name string) (string, bool) {
+	value, ok := p.Env[name]
+	return value, ok
+}
@@ -0,0 +1,101 @@
+package resolve
+
+// Variables live in scopes like shell variables: the global scope, plus one
+// scope per function body being scanned for its local declarations. Lookups
+// and assignments find the innermost scope declaring a variable, the way
+// bash and zsh scope variables dynamically.
+
+// SetEnvironment sets the variables every scan starts with, as if exported by
+// the parent process. Files may still reassign or unset them. A leading ~ and
+// references to $HOME in the values are expanded.
+func (r *PathResolver) SetEnvironment(vars map[string]string) {
+	r.environment = make(map[string]string)
+	r.ResetVariables()
+
+	for name, value := range vars {
+		if expanded, ok := r.expandValue(value); ok {
+			value = expanded
+		}
+		r.environment[name] = value
+	}
+	r.ResetVariables()
+}
+
+// SetVariable sets a custom variable for path expansion
+func (r *PathResolver) SetVariable(name, value string) {
+	r.scopeOf(name)[name] = value
+}
+
+// Assign evaluates an assignment's value like the shell does and stores the
+// result, in the innermost function scope if local is set. If the value can't
+// be evaluated the variable becomes unknown. Returns the stored value.
+func (r *PathResolver) Assign(name, value string, local bool) (string, bool) {
+	scope := r.scopeOf(name)
+	if local {
+		scope = r.scopes[len(r.scopes)-1]
+	}
+
+	expanded, ok := r.expandValue(value)
+	if !ok {
+		delete(scope, name)
+		return "", false
+	}
+	scope[name] = expanded
+	return expanded, true
+}
+
+// UnsetVariable removes a variable from the innermost scope declaring it
+func (r *PathResolver) UnsetVariable(name string) {
+	delete(r.scopeOf(name), name)
+}
+
+// PushScope opens the scope of a function body
+func (r *PathResolver) PushScope() {
+	r.scopes = append(r.scopes, make(map[string]string))
+}
+
+// PopScope closes the innermost function scope, dropping its local variables
+func (r *PathResolver) PopScope() {
+	if len(r.scopes) > 1 {
+		r.scopes = r.scopes[:len(r.scopes)-1]
+	}
+}
+
+// InFunctionScope reports whether a function scope is open
+func (r *PathResolver) InFunctionScope() bool {
+	return len(r.scopes) > 1
+}
+
+// ResetVariables forgets every variable set by scanned files, going back to the environment
+func (r *PathResolver) ResetVariables() {
+	global := make(map[string]string, len(r.environment))
+	for name, value := range r.environment {
+		global[name] = value
+	}
+	r.scopes = []map[string]string{global}
+}
+
+// lookupVariable returns the value of a variable from the innermost scope declaring it
+func (r *PathResolver) lookupVariable(name string) (string, bool) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if value, ok := r.scopes[i][name]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// scopeOf returns the innermost scope declaring a variable, or the global scope
+func (r *PathResolver) scopeOf(name string) map[string]string {
+	for i := len(r.scopes) - 1; i > 0; i-- {
+		if _, ok := r.scopes[i][name]; ok {
+			return r.scopes[i]
+		}
+	}
+	return r.scopes[0]
+}
+
+// LookupVariable returns the value of a variable as the scanned files left it
+func (r *PathResolver) LookupVariable(name string) (string, bool) {
+	return r.lookupVariable(name)
+}
@@ -0,0 +1,193 @@
+package resolve
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// expandVariables expands $VAR and ${VAR} references in the path, including
+// the POSIX parameter expansions ${VAR:-word}, ${VAR-word}, ${VAR:=word},
+// ${VAR=word}, ${VAR:+word} and ${VAR+word}. It fails on unknown variables and
+// on expansions and command substitutions it can't evaluate.
+func (r *PathResolver) expandVariables(path string) (string, bool) {
+	var b strings.Builder
+
+	for i := 0; i < len(path); i++ {
+		if path[i] == '`' || strings.HasPrefix(path[i:], "$(") {
+			// Command substitutions are never run
+			return "", false
+		}
+
+		if path[i] != '$' || i+1 >= len(path) {
+			b.WriteByte(path[i])
+			continue
+		}
+
+		if path[i+1] == '{' {
+			end := matchingBrace(path, i+1)
+			if end < 0 {
+				return "", false
+			}
+			value, ok := r.expandBraced(path[i+2 : end])
+			if !ok {
+				return "", false
+			}
+			b.WriteString(value)
+			i = end
+			continue
+		}
+
+		n := identifierLen(path[i+1:])
+		if n == 0 {
+			// Special parameters like $0 or $@ are kept as written
+			b.WriteByte('$')
+			continue
+		}
+
+		value, ok := r.lookup(path[i+1 : i+1+n])
+		if !ok {
+			return "", false
+		}
+		b.WriteString(value)
+		i += n
+	}
+
+	return b.String(), true
+}
+
+// Expand evaluates the value of an assignment, whose quotes are already
+// removed, like Assign does without storing it. Unlike ResolvePath, the result
+// isn't a path: it may be relative or hold several directories.
+func (r *PathResolver) Expand(value string) (string, bool) {
+	return r.expandValue(value)
+}
+
+// ExpandWord evaluates a word as written, removing its quotes first
+func (r *PathResolver) ExpandWord(word string) (string, bool) {
+	return r.expandWord(word)
+}
+
+// expandBraced evaluates the inside of a ${...} expansion
+func (r *PathResolver) expandBraced(inner string) (string, bool) {
+	n := identifierLen(inner)
+	if n == 0 {
+		// ${#var}, ${!var}, ${0:h} and friends
+		return "", false
+	}
+
+	name, rest := inner[:n], inner[n:]
+	value, set := r.lookup(name)
+	if rest == "" {
+		return value, set
+	}
+
+	colon := strings.HasPrefix(rest, ":")
+	op := strings.TrimPrefix(rest, ":")
+	if op == "" {
+		return "", false
+	}
+	word := op[1:]
+
+	// With a colon, an empty value counts as unset
+	null := !set || (colon && value == "")
+
+	switch op[0] {
+	case '-':
+		if null {
+			return r.expandWord(word)
+		}
+		return value, true
+	case '=':
+		if null {
+			expanded, ok := r.expandWord(word)
+			if ok {
+				r.SetVariable(name, expanded)
+			}
+			return expanded, ok
+		}
+		return value, true
+	case '+':
+		if null {
+			return "", true
+		}
+		return r.expandWord(word)
+	default:
+		// Substrings, pattern removal and other expansions aren't evaluated
+		return "", false
+	}
+}
+
+// expandWord expands the word of a parameter expansion, which may be quoted
+// and start with a tilde
+func (r *PathResolver) expandWord(word string) (string, bool) {
+	return r.expandValue(unquote(word))
+}
+
+// expandValue expands an unquoted value that may start with a tilde
+func (r *PathResolver) expandValue(word string) (string, bool) {
+	if strings.HasPrefix(word, "~/") {
+		word = filepath.Join(r.homeDir, word[2:])
+	} else if word == "~" {
+		word = r.homeDir
+	}
+	return r.expandVariables(word)
+}
+
+// lookup returns the value of a variable and whether it is set
+func (r *PathResolver) lookup(name string) (string, bool) {
+	if value, ok := r.lookupVariable(name); ok {
+		return value, true
+	}
+
+	switch name {
+	case "HOME":
+		return r.homeDir, true
+	case "XDG_CONFIG_HOME":
+		return r.xdgConfigHome, true
+	default:
+		return "", false
+	}
+}
+
+// identifierLen returns the length of the variable name at the start of s
+func identifierLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		isLetter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		if !isLetter && (i == 0 || ch < '0' || ch > '9') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// matchingBrace returns the index of the } closing the { at open, skipping
+// quoted text and nested expansions, or -1
+func matchingBrace(s string, open int) int {
+	depth := 0
+	var quote byte
+
+	for i := open; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else if ch == '\\' && quote == '"' {
+				i++
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '\\':
+			i++
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -5,18 +5,62 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/oscar.rivas/falias/internal/parser"
 )
 
 var (
-	// Regex to match $VAR or ${VAR} patterns
-	varPattern = regexp.MustCompile(`\$\{?([A-Z_][A-Z0-9_]*)\}?`)
+	// Matches a valid shell variable name
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// Expressions expanding to the directory of the file being sourced:
+	// $(dirname "${BASH_SOURCE[0]}"), `dirname $0`, ${0:A:h}, ${0:h} and ${BASH_SOURCE%/*}
+	sourceDirPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\$\(\s*dirname\s+(?:--\s+)?"?\$\{?(?:BASH_SOURCE(?:\[0\])?|0)\}?"?\s*\)`),
+		regexp.MustCompile("`\\s*dirname\\s+(?:--\\s+)?\"?\\$\\{?(?:BASH_SOURCE(?:\\[0\\])?|0)\\}?\"?\\s*`"),
+		regexp.MustCompile(`\$\{0(?::[aA])?:h\}`),
+		regexp.MustCompile(`\$\{\$\{\(%\):-%[xN]\}(?::[aA])?:h\}`),
+		regexp.MustCompile(`\$\{(?:BASH_SOURCE(?:\[0\])?|0)%/\*\}`),
+		regexp.MustCompile(`\(status dirname\)|\(dirname \(status (?:filename|current-filename|--current-filename|-f)\)\)`), // fish
+	}
+
+	// Expressions expanding to the path of the file being sourced:
+	// ${BASH_SOURCE[0]}, $BASH_SOURCE, ${0:A} and ${(%):-%x}
+	sourceFilePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\$\{BASH_SOURCE(?:\[0\])?\}|\$BASH_SOURCE\b`),
+		regexp.MustCompile(`\$\{0:[aA]\}`),
+		regexp.MustCompile(`\$\{\(%\):-%[xN]\}`),
+	}
+
+	// Matches $(cd "dir" && pwd), which prints dir once it has been expanded
+	cdPwdPattern = regexp.MustCompile(`\$\(\s*cd\s+"?([^"$()]*)"?\s*(?:&&|;)\s*pwd(?:\s+-P)?\s*\)`)
 )
 
+// declarationKeywords are the builtins that declare variables
+var declarationKeywords = map[string]bool{
+	"export":   true,
+	"local":    true,
+	"typeset":  true,
+	"declare":  true,
+	"readonly": true,
+}
+
+// leadingKeywords are the reserved words that may start a command on the same
+// line, as in: if [ -n "$X" ]; then FOO=bar; fi
+var leadingKeywords = map[string]bool{
+	"then": true,
+	"do":   true,
+	"else": true,
+	"{":    true,
+}
+
 // PathResolver handles expansion of shell path variables
 type PathResolver struct {
 	homeDir        string
 	xdgConfigHome  string
-	customVars     map[string]string
+	environment    map[string]string   // Variables every scan starts with
+	scopes         []map[string]string // Variable scopes, global first and innermost function last
+	currentFile    string              // File being scanned, relative paths resolve against its directory
 }
 
 // NewPathResolver creates a new path resolver with environment defaults
@@ -30,13 +74,19 @@ func NewPathResolver() *PathResolver {
 	return &PathResolver{
 		homeDir:       homeDir,
 		xdgConfigHome: xdgConfigHome,
-		customVars:    make(map[string]string),
+		environment:   make(map[string]string),
+		scopes:        []map[string]string{make(map[string]string)},
 	}
 }
 
-// SetVariable sets a custom variable for path expansion
-func (r *PathResolver) SetVariable(name, value string) {
-	r.customVars[name] = value
+// SetCurrentFile sets the file whose source statements are being resolved
+func (r *PathResolver) SetCurrentFile(path string) {
+	r.currentFile = path
+}
+
+// CurrentFile returns the file whose source statements are being resolved
+func (r *PathResolver) CurrentFile() string {
+	return r.currentFile
 }
 
 // ResolvePath attempts to resolve a shell path to an absolute path
@@ -46,8 +96,8 @@ func (r *PathResolver) ResolvePath(path string) (string, bool) {
 		return "", false
 	}
 
-	// Remove surrounding quotes
-	path = removeQuotes(path)
+	// Remove quotes
+	path = unquote(path)
 
 	// Handle tilde expansion
 	if strings.HasPrefix(path, "~/") {
@@ -56,6 +106,9 @@ func (r *PathResolver) ResolvePath(path string) (string, bool) {
 		path = r.homeDir
 	}
 
+	// Expand references to the sourcing file
+	path = r.expandSourceFile(path)
+
 	// Expand variables
 	expanded, ok := r.expandVariables(path)
 	if !ok {
@@ -65,6 +118,11 @@ func (r *PathResolver) ResolvePath(path string) (string, bool) {
 	// Clean the path
 	expanded = filepath.Clean(expanded)
 
+	// Relative paths are relative to the sourcing file
+	if !filepath.IsAbs(expanded) && r.currentFile != "" {
+		expanded = filepath.Join(filepath.Dir(r.currentFile), expanded)
+	}
+
 	// Make absolute if not already
 	if !filepath.IsAbs(expanded) {
 		return "", false
@@ -73,50 +131,31 @@ func (r *PathResolver) ResolvePath(path string) (string, bool) {
 	return expanded, true
 }
 
-// expandVariables expands $VAR and ${VAR} patterns in the path
-func (r *PathResolver) expandVariables(path string) (string, bool) {
-	result := path
-	foundUnknown := false
-
-	// Find all variable references
-	matches := varPattern.FindAllStringSubmatch(path, -1)
-	for _, match := range matches {
-		fullMatch := match[0]
-		varName := match[1]
-
-		var replacement string
-		var found bool
-
-		// Check known variables
-		switch varName {
-		case "HOME":
-			replacement = r.homeDir
-			found = true
-		case "XDG_CONFIG_HOME":
-			replacement = r.xdgConfigHome
-			found = true
-		default:
-			// Check custom variables
-			if val, ok := r.customVars[varName]; ok {
-				replacement = val
-				found = true
-			} else {
-				// Unknown variable
-				foundUnknown = true
-			}
-		}
-
-		if found {
-			result = strings.Replace(result, fullMatch, replacement, 1)
-		}
+// expandSourceFile expands the idioms scripts use to locate the file being
+// sourced and its directory
+func (r *PathResolver) expandSourceFile(path string) string {
+	if r.currentFile == "" {
+		return path
 	}
 
-	// If we found unknown variables, return failure
-	if foundUnknown {
-		return "", false
+	dir := filepath.Dir(r.currentFile)
+	for _, pattern := range sourceDirPatterns {
+		path = pattern.ReplaceAllLiteralString(path, dir)
+	}
+	for _, pattern := range sourceFilePatterns {
+		path = pattern.ReplaceAllLiteralString(path, r.currentFile)
 	}
+	return cdPwdPattern.ReplaceAllString(path, "$1")
+}
 
-	return result, true
+// unquote removes shell quoting from a path made of a single word, such as
+// "$(dirname "$0")"/x.sh, and falls back to removing surrounding quotes
+func unquote(s string) string {
+	words, err := parser.Tokenize(strings.TrimSpace(s))
+	if err == nil && len(words) == 1 && words[0].Kind == parser.WordLiteral {
+		return words[0].Value
+	}
+	return removeQuotes(s)
 }
 
 // removeQuotes removes surrounding single or double quotes
@@ -148,20 +187,109 @@ func Canonicalize(path string) (string, error) {
 	return canonPath, nil
 }
 
+// Assignment is a variable assignment found in a shell statement
+type Assignment struct {
+	Name  string
+	Value string // Value with quotes removed, expansions left as written
+}
+
+// ParseVariableAssignments parses the variable assignments of a logical line:
+// VAR=value, export VAR="value", local var='value', typeset -g VAR=value ...
+// Assignments prefixed to a command (VAR=value cmd) only apply to that command
+// and are ignored, as are array assignments.
+func ParseVariableAssignments(line string) []Assignment {
+	assignments := make([]Assignment, 0)
+
+	words, _ := parser.Tokenize(strings.TrimSpace(line))
+	for _, cmd := range parser.SplitCommands(dropArrayAssignments(words)) {
+		for len(cmd) > 0 && cmd[0].Kind == parser.WordLiteral && leadingKeywords[cmd[0].Raw] {
+			cmd = cmd[1:]
+		}
+		if len(cmd) == 0 {
+			continue
+		}
+
+		declaration := declarationKeywords[cmd[0].Raw]
+		args := cmd
+		if declaration {
+			args = cmd[1:]
+		}
+
+		var found []Assignment
+		isCommand := false
+		for _, w := range args {
+			if declaration && (strings.HasPrefix(w.Value, "-") || strings.HasPrefix(w.Value, "+")) {
+				continue // Options such as export -n or typeset -gx
+			}
+
+			name, value, ok := strings.Cut(w.Value, "=")
+			if !ok || !identifierPattern.MatchString(name) || !strings.HasPrefix(w.Raw, name+"=") {
+				if declaration {
+					continue // Declaration without a value: export VAR
+				}
+				isCommand = true
+				break
+			}
+			if strings.HasPrefix(value, "(") && strings.HasPrefix(w.Raw, name+"=(") {
+				continue // Array assignment
+			}
+			found = append(found, Assignment{Name: name, Value: value})
+		}
+
+		if !isCommand {
+			assignments = append(assignments, found...)
+		}
+	}
+
+	return assignments
+}
+
+// ParseUnset returns the variables removed by the unset statements of a logical line
+func ParseUnset(line string) []string {
+	names := make([]string, 0)
+
+	words, _ := parser.Tokenize(strings.TrimSpace(line))
+	for _, cmd := range parser.SplitCommands(words) {
+		if cmd[0].Raw != "unset" {
+			continue
+		}
+
+		for _, w := range cmd[1:] {
+			if w.Value == "-f" {
+				break // unset -f removes functions
+			}
+			if identifierPattern.MatchString(w.Value) {
+				names = append(names, w.Value)
+			}
+		}
+	}
+
+	return names
+}
+
 // ParseVariableAssignment attempts to parse a variable assignment like VAR="value"
-// Returns variable name, value, and success boolean
+// Returns the name and value of the first assignment on the line, and success boolean
 func ParseVariableAssignment(line string) (string, string, bool) {
-	line = strings.TrimSpace(line)
-
-	// Match VAR=value, VAR="value", or VAR='value'
-	re := regexp.MustCompile(`^([A-Z_][A-Z0-9_]*)=(.+)$`)
-	matches := re.FindStringSubmatch(line)
-	if matches == nil {
+	assignments := ParseVariableAssignments(line)
+	if len(assignments) == 0 {
 		return "", "", false
 	}
+	return assignments[0].Name, assignments[0].Value, true
+}
 
-	varName := matches[1]
-	value := removeQuotes(matches[2])
-
-	return varName, value, true
+// dropArrayAssignments removes array assignments such as arr=(a b) from words
+func dropArrayAssignments(words []parser.Word) []parser.Word {
+	kept := make([]parser.Word, 0, len(words))
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if w.Kind == parser.WordLiteral && strings.HasSuffix(w.Raw, "=") &&
+			i+1 < len(words) && words[i+1].Kind == parser.WordOperator && words[i+1].Value == "(" {
+			for i < len(words) && !(words[i].Kind == parser.WordOperator && words[i].Value == ")") {
+				i++
+			}
+			continue
+		}
+		kept = append(kept, w)
+	}
+	return kept
 }
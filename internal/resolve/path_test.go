@@ -159,8 +159,60 @@ func TestParseVariableAssignment(t *testing.T) {
 			wantOk: false,
 		},
 		{
-			name:   "export statement",
-			line:   "export FOO=bar",
+			name:      "export statement",
+			line:      "export FOO=bar",
+			wantName:  "FOO",
+			wantValue: "bar",
+			wantOk:    true,
+		},
+		{
+			name:      "lowercase local",
+			line:      `local dir="$HOME/src"`,
+			wantName:  "dir",
+			wantValue: "$HOME/src",
+			wantOk:    true,
+		},
+		{
+			name:      "typeset with options",
+			line:      "typeset -gx ZSH_CUSTOM=${ZSH_CUSTOM:-~/.oh-my-zsh/custom}",
+			wantName:  "ZSH_CUSTOM",
+			wantValue: "${ZSH_CUSTOM:-~/.oh-my-zsh/custom}",
+			wantOk:    true,
+		},
+		{
+			name:      "declare after a guard",
+			line:      `[ -d ~/.dotfiles ] && declare -r DOTFILES=~/.dotfiles`,
+			wantName:  "DOTFILES",
+			wantValue: "~/.dotfiles",
+			wantOk:    true,
+		},
+		{
+			name:      "after then",
+			line:      `if [ -n "$X" ]; then FOO=bar; fi`,
+			wantName:  "FOO",
+			wantValue: "bar",
+			wantOk:    true,
+		},
+		{
+			name:      "in a brace group",
+			line:      `{ export DOTFILES=~/.dotfiles; }`,
+			wantName:  "DOTFILES",
+			wantValue: "~/.dotfiles",
+			wantOk:    true,
+		},
+		{
+			name:   "command environment prefix",
+			line:   "FOO=bar make",
+			wantOk: false,
+		},
+		{
+			name:   "array assignment",
+			line:   "plugins=(git docker)",
+			wantOk: false,
+		},
+		{
+			name:   "declaration without value",
+			line:   "export FOO",
 			wantOk: false,
 		},
 	}
@@ -204,3 +256,138 @@ func findInString(s, substr string) bool {
 	}
 	return false
 }
+
+func TestResolvePathRelativeToCurrentFile(t *testing.T) {
+	resolver := NewPathResolver()
+	resolver.SetCurrentFile("/home/me/dotfiles/zshrc")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "relative path", path: "./aliases.sh", want: "/home/me/dotfiles/aliases.sh"},
+		{name: "bare relative path", path: "lib/git.sh", want: "/home/me/dotfiles/lib/git.sh"},
+		{name: "parent directory", path: "../.aliases", want: "/home/me/.aliases"},
+		{name: "dirname BASH_SOURCE", path: `"$(dirname "${BASH_SOURCE[0]}")/x.sh"`, want: "/home/me/dotfiles/x.sh"},
+		{name: "dirname quoted separately", path: `"$(dirname "$0")"/x.sh`, want: "/home/me/dotfiles/x.sh"},
+		{name: "cd and pwd", path: `"$(cd "$(dirname "$BASH_SOURCE")" && pwd)/x.sh"`, want: "/home/me/dotfiles/x.sh"},
+		{name: "BASH_SOURCE suffix removal", path: `${BASH_SOURCE%/*}/x.sh`, want: "/home/me/dotfiles/x.sh"},
+		{name: "zsh absolute head", path: `"${0:A:h}/git.zsh"`, want: "/home/me/dotfiles/git.zsh"},
+		{name: "zsh head", path: `${0:h}/git.zsh`, want: "/home/me/dotfiles/git.zsh"},
+		{name: "fish status dirname", path: `(status dirname)/abbr.fish`, want: "/home/me/dotfiles/abbr.fish"},
+		{name: "zsh prompt expansion", path: `${${(%):-%x}:A:h}/git.zsh`, want: "/home/me/dotfiles/git.zsh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolver.ResolvePath(tt.path)
+			if !ok || got != tt.want {
+				t.Errorf("ResolvePath(%s) = %q, %v, want %q", tt.path, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParameterExpansion(t *testing.T) {
+	homeDir, _ := os.UserHomeDir()
+
+	tests := []struct {
+		name   string
+		path   string
+		want   string
+		wantOk bool
+	}{
+		{name: "default for unset", path: `${DOTFILES:-$HOME/.dotfiles}/zsh`, want: homeDir + "/.dotfiles/zsh", wantOk: true},
+		{name: "quoted default", path: `${DOTFILES:-"$HOME/.dotfiles"}/zsh`, want: homeDir + "/.dotfiles/zsh", wantOk: true},
+		{name: "default not used when set", path: `${set_var:-/nope}/x`, want: "/opt/x", wantOk: true},
+		{name: "colon default for empty", path: `${empty_var:-/default}`, want: "/default", wantOk: true},
+		{name: "no colon keeps empty", path: `/a${empty_var-/default}`, want: "/a", wantOk: true},
+		{name: "alternate value", path: `/a${set_var:+/b}`, want: "/a/b", wantOk: true},
+		{name: "alternate for unset", path: `/a${unset_var:+/b}`, want: "/a", wantOk: true},
+		{name: "tilde in default", path: `${ZSH_CUSTOM:=~/.oh-my-zsh/custom}/aliases.zsh`, want: homeDir + "/.oh-my-zsh/custom/aliases.zsh", wantOk: true},
+		{name: "assigned default is kept", path: `$ZSH_CUSTOM/x`, want: homeDir + "/.oh-my-zsh/custom/x", wantOk: true},
+		{name: "nested default", path: `${A:-${B:-/nested}}`, want: "/nested", wantOk: true},
+		{name: "lowercase variable", path: `$set_var/file`, want: "/opt/file", wantOk: true},
+		{name: "unknown lowercase variable", path: `$unset_var/file`, wantOk: false},
+		{name: "unsupported expansion", path: `${set_var%/*}/file`, wantOk: false},
+	}
+
+	// Cases run in order: := assigns the variable for the next case
+	resolver := NewPathResolver()
+	resolver.SetVariable("set_var", "/opt")
+	resolver.SetVariable("empty_var", "")
+
+	for _, tt := range tests {
+		got, ok := resolver.ResolvePath(tt.path)
+		if ok != tt.wantOk || (ok && got != tt.want) {
+			t.Errorf("%s: ResolvePath(%s) = %q, %v, want %q, %v", tt.name, tt.path, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	resolver := NewPathResolver()
+	homeDir, _ := os.UserHomeDir()
+
+	if _, ok := resolver.Assign("DOTFILES", "~/.dotfiles", false); !ok {
+		t.Fatalf("Assign() = false, want true")
+	}
+	if got, _ := resolver.ResolvePath("$DOTFILES/zsh"); got != homeDir+"/.dotfiles/zsh" {
+		t.Errorf("ResolvePath() = %q, want value expanded at assignment", got)
+	}
+
+	if _, ok := resolver.Assign("DOTFILES", "$(pwd)/x", false); ok {
+		t.Errorf("Assign() of a command substitution = true, want false")
+	}
+	if _, ok := resolver.ResolvePath("$DOTFILES/zsh"); ok {
+		t.Errorf("variable with an unknown value should not resolve")
+	}
+}
+
+func TestParseUnset(t *testing.T) {
+	if got := ParseUnset("unset -v A b; unset -f fn"); len(got) != 2 || got[0] != "A" || got[1] != "b" {
+		t.Errorf("ParseUnset() = %v, want [A b]", got)
+	}
+}
+
+func TestFunctionScope(t *testing.T) {
+	resolver := NewPathResolver()
+	resolver.SetVariable("DIR", "/global")
+
+	resolver.PushScope()
+	resolver.Assign("DIR", "/local", true)
+	if got, _ := resolver.ResolvePath("$DIR"); got != "/local" {
+		t.Errorf("ResolvePath() in function = %q, want /local", got)
+	}
+	resolver.PopScope()
+
+	if got, _ := resolver.ResolvePath("$DIR"); got != "/global" {
+		t.Errorf("ResolvePath() after function = %q, want /global", got)
+	}
+
+	resolver.UnsetVariable("DIR")
+	if _, ok := resolver.ResolvePath("$DIR"); ok {
+		t.Errorf("ResolvePath() after unset should fail")
+	}
+}
+
+func TestSetEnvironment(t *testing.T) {
+	resolver := NewPathResolver()
+	homeDir, _ := os.UserHomeDir()
+	resolver.SetEnvironment(map[string]string{"ZSH": "~/.oh-my-zsh", "DOTFILES": "/srv/dotfiles"})
+
+	if got, _ := resolver.ResolvePath("$ZSH/oh-my-zsh.sh"); got != homeDir+"/.oh-my-zsh/oh-my-zsh.sh" {
+		t.Errorf("ResolvePath() = %q, want environment value with ~ expanded", got)
+	}
+
+	// Files may reassign environment variables, and each scan starts over
+	resolver.Assign("DOTFILES", "/tmp/other", false)
+	if got, _ := resolver.ResolvePath("$DOTFILES"); got != "/tmp/other" {
+		t.Errorf("ResolvePath() after assignment = %q, want /tmp/other", got)
+	}
+	resolver.ResetVariables()
+	if got, _ := resolver.ResolvePath("$DOTFILES"); got != "/srv/dotfiles" {
+		t.Errorf("ResolvePath() after reset = %q, want /srv/dotfiles", got)
+	}
+}
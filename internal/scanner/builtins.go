@@ -0,0 +1,89 @@
+package scanner
+
+import "github.com/oscar.rivas/falias/internal/model"
+
+// shellCatalog lists the commands a shell implements itself
+type shellCatalog struct {
+	keywords map[string]bool // Reserved words
+	builtins map[string]bool
+}
+
+// catalogs maps each shell to its reserved words and builtins
+var catalogs = map[string]shellCatalog{
+	"bash": {
+		keywords: setOf("!", "[[", "]]", "{", "}", "case", "coproc", "do", "done", "elif", "else", "esac",
+			"fi", "for", "function", "if", "in", "select", "then", "time", "until", "while"),
+		builtins: setOf(".", ":", "[", "alias", "bg", "bind", "break", "builtin", "caller", "cd", "command",
+			"compgen", "complete", "compopt", "continue", "declare", "dirs", "disown", "echo", "enable", "eval",
+			"exec", "exit", "export", "false", "fc", "fg", "getopts", "hash", "help", "history", "jobs", "kill",
+			"let", "local", "logout", "mapfile", "popd", "printf", "pushd", "pwd", "read", "readarray",
+			"readonly", "return", "set", "shift", "shopt", "source", "suspend", "test", "times", "trap", "true",
+			"type", "typeset", "ulimit", "umask", "unalias", "unset", "wait"),
+	},
+	"zsh": {
+		keywords: setOf("!", "[[", "]]", "{", "}", "case", "coproc", "do", "done", "elif", "else", "end",
+			"esac", "fi", "for", "foreach", "function", "if", "in", "nocorrect", "repeat", "select", "then",
+			"time", "until", "while"),
+		builtins: setOf("-", ".", ":", "[", "alias", "autoload", "bg", "bindkey", "break", "builtin", "bye",
+			"cd", "chdir", "command", "continue", "declare", "dirs", "disable", "disown", "echo", "emulate",
+			"enable", "eval", "exec", "exit", "export", "false", "fc", "fg", "float", "functions", "getln",
+			"getopts", "hash", "history", "integer", "jobs", "kill", "let", "limit", "local", "log", "logout",
+			"noglob", "popd", "print", "printf", "pushd", "pushln", "pwd", "r", "read", "readonly", "rehash",
+			"return", "sched", "set", "setopt", "shift", "source", "suspend", "test", "times", "trap", "true",
+			"ttyctl", "type", "typeset", "ulimit", "umask", "unalias", "unfunction", "unhash", "unlimit",
+			"unset", "unsetopt", "vared", "wait", "whence", "where", "which", "zcompile", "zle", "zmodload",
+			"zparseopts", "zstyle"),
+	},
+	"fish": {
+		keywords: setOf("and", "begin", "break", "builtin", "case", "command", "continue", "else", "end",
+			"exec", "for", "function", "if", "not", "or", "return", "switch", "time", "while"),
+		builtins: setOf(".", ":", "[", "abbr", "argparse", "bg", "bind", "block", "breakpoint", "cd",
+			"commandline", "complete", "contains", "count", "disown", "echo", "emit", "eval", "exit", "false",
+			"fg", "functions", "history", "jobs", "math", "path", "printf", "pwd", "random", "read", "realpath",
+			"set", "set_color", "source", "status", "string", "test", "true", "type", "ulimit", "wait"),
+	},
+}
+
+// criticalBuiltins change the state or control flow of the shell; scripts
+// and plugins sourced after the alias rely on them behaving normally
+var criticalBuiltins = setOf(".", ":", "[", "alias", "autoload", "break", "builtin", "cd", "command",
+	"continue", "declare", "emulate", "enable", "disable", "eval", "exec", "exit", "export", "false",
+	"functions", "local", "read", "readonly", "return", "set", "setopt", "shift", "shopt", "source",
+	"status", "string", "test", "trap", "true", "typeset", "unalias", "unset", "unsetopt")
+
+// interactiveBuiltins are mostly typed by hand, so aliasing them rarely breaks anything
+var interactiveBuiltins = setOf("abbr", "bg", "bind", "bindkey", "bye", "commandline", "compgen",
+	"complete", "compopt", "dirs", "disown", "fc", "fg", "help", "history", "jobs", "logout", "popd",
+	"pushd", "r", "rehash", "sched", "set_color", "suspend", "times", "ttyctl", "vared", "where",
+	"which", "zle", "zstyle")
+
+// ShellCommand returns whether name is a reserved word or builtin of shell,
+// and the severity of hiding it behind an alias
+func ShellCommand(shell string, name string) (model.ShadowKind, model.Severity, bool) {
+	catalog, ok := catalogs[shell]
+	if !ok {
+		return "", "", false
+	}
+
+	switch {
+	case catalog.keywords[name]:
+		return model.ShadowKeyword, model.SeverityHigh, true
+	case !catalog.builtins[name]:
+		return "", "", false
+	case criticalBuiltins[name]:
+		return model.ShadowBuiltin, model.SeverityHigh, true
+	case interactiveBuiltins[name]:
+		return model.ShadowBuiltin, model.SeverityLow, true
+	default:
+		return model.ShadowBuiltin, model.SeverityMedium, true
+	}
+}
+
+// setOf returns a set of names
+func setOf(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
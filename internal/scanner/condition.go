@@ -0,0 +1,428 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+// Command is a simple command and the conditions it runs under
+type Command struct {
+	Words      []parser.Word // Words of the command, without the keywords of its enclosing blocks
+	Conditions []model.Condition
+}
+
+// Text returns the command as written, with words separated by single spaces
+func (c Command) Text() string {
+	return joinRaw(c.Words)
+}
+
+// commandText returns the text of a command without its redirections
+func commandText(words []parser.Word) string {
+	var text []string
+	for _, cmd := range parser.SplitCommands(words) {
+		text = append(text, joinRaw(cmd))
+	}
+	return strings.Join(text, " ")
+}
+
+// conditionBlock is an if, case or group whose body is still open
+type conditionBlock struct {
+	kind    string            // if, case, or group for braces, subshells and fish blocks
+	branch  []model.Condition // Conditions of the current branch
+	tests   []model.Condition // Tests of the previous branches, which an else branch negates
+	reading bool              // Reading the test of an if or elif, or the patterns of a case branch
+	test    []string          // Words of the test or patterns being read
+	word    string            // Word a case matches
+}
+
+// ConditionTracker follows the if, case and && structure of a file so that
+// each command can be attached the conditions it runs under. Blocks span
+// several lines, so the tracker must be fed every logical line of a file in order.
+type ConditionTracker struct {
+	fish   bool
+	blocks []conditionBlock // Open blocks, innermost last
+	last   string           // fish: the previous command, tested by and/or
+}
+
+// NewConditionTracker creates a new condition tracker for a dialect
+func NewConditionTracker(dialect parser.Dialect) *ConditionTracker {
+	return &ConditionTracker{fish: dialect == parser.DialectFish}
+}
+
+// Conditions returns the conditions of the open blocks
+func (t *ConditionTracker) Conditions() []model.Condition {
+	conditions := make([]model.Condition, 0)
+	for _, block := range t.blocks {
+		conditions = append(conditions, block.branch...)
+	}
+	return conditions
+}
+
+// ParseLine feeds the next logical line and returns its commands
+func (t *ConditionTracker) ParseLine(line string) []Command {
+	dialect := parser.DialectPOSIX
+	if t.fish {
+		dialect = parser.DialectFish
+	}
+	words, _ := parser.TokenizeDialect(line, dialect)
+
+	var commands []Command
+	var current []parser.Word // Words of the command being read
+	var chain []string        // Commands of the and-or list so far
+	var guard []model.Condition
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		cmd := current
+		current = nil
+
+		rest, ok := t.keywords(cmd, &guard)
+		if t.readingTest() {
+			if len(rest) > 0 {
+				t.top().test = append(t.top().test, commandText(rest))
+			}
+			return
+		}
+		if len(rest) > 0 {
+			commands = append(commands, Command{Words: rest, Conditions: append(t.Conditions(), guard...)})
+			chain = append(chain, commandText(rest))
+		}
+		if ok {
+			t.last = commandText(rest)
+		}
+	}
+
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+
+		// The patterns of a case branch: pattern | pattern )
+		if block := t.top(); block != nil && block.kind == "case" && block.reading && !t.fish {
+			switch {
+			case w.Kind == parser.WordLiteral && w.Raw == "esac":
+				t.pop("case")
+			case w.Kind == parser.WordLiteral:
+				block.test = append(block.test, w.Raw)
+			case w.Value == ")":
+				t.caseBranch(block, block.test)
+			}
+			continue
+		}
+
+		// Process substitution: <(cmd) is a single word of the command
+		if w.Kind == parser.WordRedirect && i+1 < len(words) && words[i+1].Kind == parser.WordOperator && words[i+1].Value == "(" {
+			end, depth := i+2, 1
+			for ; end < len(words); end++ {
+				if words[end].Kind != parser.WordOperator {
+					continue
+				}
+				if words[end].Value == "(" {
+					depth++
+				} else if words[end].Value == ")" {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			raw := w.Raw + "(" + joinRaw(words[i+2:min(end, len(words))]) + ")"
+			current = append(current, parser.Word{Kind: parser.WordLiteral, Raw: raw, Value: raw})
+			i = end
+			continue
+		}
+
+		if w.Kind != parser.WordOperator {
+			current = append(current, w)
+			continue
+		}
+
+		switch w.Value {
+		case "&&", "||", "|":
+			flush()
+			if t.readingTest() {
+				t.top().test = append(t.top().test, w.Value)
+				continue
+			}
+			if w.Value != "|" {
+				guard = []model.Condition{{Text: strings.Join(chain, " "), Negated: w.Value == "||"}}
+			}
+			chain = append(chain, w.Value)
+		case "(":
+			// Arithmetic: (( ... )) is a single command
+			if end := arithmeticEnd(words, i); end > 0 {
+				raw := "(( " + joinRaw(words[i+2:end-1]) + " ))"
+				current = append(current, parser.Word{Kind: parser.WordLiteral, Raw: raw, Value: raw})
+				i = end
+				continue
+			}
+			if len(current) > 0 {
+				continue // Function definition: name()
+			}
+			t.blocks = append(t.blocks, conditionBlock{kind: "group", branch: guard})
+			chain, guard = nil, nil
+		case ")":
+			if len(current) > 0 && i > 0 && words[i-1].Value == "(" {
+				flush() // End of name()
+				continue
+			}
+			flush()
+			if block := t.top(); block != nil && block.kind == "case" && block.reading {
+				t.caseBranch(block, block.test) // case word in pattern)
+				continue
+			}
+			t.pop("group")
+		case ";;", ";&", ";;&", ";|":
+			flush()
+			if block := t.top(); block != nil && block.kind == "case" {
+				block.reading = true
+				block.test = nil
+			}
+			chain, guard = nil, nil
+		default:
+			// ; & and newlines end the and-or list
+			flush()
+			chain, guard = nil, nil
+		}
+	}
+	flush()
+
+	return commands
+}
+
+// keywords handles the reserved words starting a command and returns the
+// rest of the command, and whether it's a command a fish and/or could test
+func (t *ConditionTracker) keywords(words []parser.Word, guard *[]model.Condition) ([]parser.Word, bool) {
+	for len(words) > 0 {
+		w := words[0]
+		if w.Kind != parser.WordLiteral || w.Raw != w.Value {
+			return words, true
+		}
+
+		var rest []parser.Word
+		var handled bool
+		if t.fish {
+			rest, handled = t.fishKeyword(words, guard)
+		} else {
+			rest, handled = t.posixKeyword(words, guard)
+		}
+		if !handled {
+			return words, true
+		}
+		if rest == nil {
+			return nil, false
+		}
+		words = rest
+	}
+	return words, false
+}
+
+// posixKeyword handles a bash or zsh reserved word starting words
+func (t *ConditionTracker) posixKeyword(words []parser.Word, guard *[]model.Condition) ([]parser.Word, bool) {
+	block := t.top()
+
+	switch words[0].Value {
+	case "if":
+		t.blocks = append(t.blocks, conditionBlock{kind: "if", reading: true})
+	case "elif":
+		if block != nil && block.kind == "if" {
+			block.reading = true
+			block.test = nil
+		}
+	case "then":
+		if block != nil && block.kind == "if" && block.reading {
+			test := model.Condition{Text: strings.Join(block.test, " ")}
+			block.branch = append(negate(block.tests), test)
+			block.tests = append(block.tests, test)
+			block.reading = false
+		}
+	case "else":
+		if block != nil && block.kind == "if" {
+			block.branch = negate(block.tests)
+		}
+	case "fi":
+		t.pop("if")
+	case "case":
+		if len(words) < 3 {
+			return nil, true
+		}
+		t.blocks = append(t.blocks, conditionBlock{kind: "case", word: words[1].Raw, reading: true})
+		// case word in pattern) ...: the patterns follow
+		if len(words) > 3 {
+			t.top().test = rawWords(words[3:])
+		}
+		return nil, true
+	case "esac":
+		t.pop("case")
+	case "{":
+		t.blocks = append(t.blocks, conditionBlock{kind: "group", branch: *guard})
+		*guard = nil
+	case "}":
+		t.pop("group")
+	case "function":
+		// function name {: the brace opens the body
+		if words[len(words)-1].Value == "{" {
+			t.blocks = append(t.blocks, conditionBlock{kind: "group"})
+		}
+		return nil, true
+	case "do", "done":
+	default:
+		return nil, false
+	}
+
+	if len(words) == 1 {
+		return nil, true
+	}
+	return words[1:], true
+}
+
+// fishKeyword handles a fish reserved word starting words
+func (t *ConditionTracker) fishKeyword(words []parser.Word, guard *[]model.Condition) ([]parser.Word, bool) {
+	block := t.top()
+
+	switch words[0].Value {
+	case "if":
+		test := model.Condition{Text: joinRaw(words[1:])}
+		t.blocks = append(t.blocks, conditionBlock{kind: "if", branch: []model.Condition{test}, tests: []model.Condition{test}})
+		return nil, true
+	case "else":
+		if block == nil || block.kind != "if" {
+			return nil, true
+		}
+		block.branch = negate(block.tests)
+		if len(words) > 1 && words[1].Value == "if" {
+			test := model.Condition{Text: joinRaw(words[2:])}
+			block.branch = append(block.branch, test)
+			block.tests = append(block.tests, test)
+			return nil, true
+		}
+	case "switch":
+		if len(words) > 1 {
+			t.blocks = append(t.blocks, conditionBlock{kind: "case", word: words[1].Raw})
+		}
+		return nil, true
+	case "case":
+		if block != nil && block.kind == "case" {
+			t.caseBranch(block, rawWords(words[1:]))
+		}
+		return nil, true
+	case "function", "for", "while", "begin":
+		t.blocks = append(t.blocks, conditionBlock{kind: "group"})
+		if words[0].Value != "begin" {
+			return nil, true
+		}
+	case "end":
+		if len(t.blocks) > 0 {
+			t.blocks = t.blocks[:len(t.blocks)-1]
+		}
+	case "and", "or":
+		if t.last != "" {
+			*guard = []model.Condition{{Text: t.last, Negated: words[0].Value == "or"}}
+		}
+	case "not":
+	default:
+		return nil, false
+	}
+
+	if len(words) == 1 {
+		return nil, true
+	}
+	return words[1:], true
+}
+
+// caseBranch starts the branch of a case matching patterns: it runs when no
+// previous branch matched and one of its patterns does
+func (t *ConditionTracker) caseBranch(block *conditionBlock, patterns []string) {
+	block.branch = negate(block.tests)
+	block.reading = false
+
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return // Matches anything
+		}
+	}
+	if len(patterns) == 0 {
+		return
+	}
+
+	separator := "|"
+	if t.fish {
+		separator = " "
+	}
+	test := model.Condition{Text: "case " + block.word + " in " + strings.Join(patterns, separator)}
+	block.branch = append(block.branch, test)
+	block.tests = append(block.tests, test)
+}
+
+// arithmeticEnd returns the index of the closing )) of an arithmetic command
+// whose (( starts at words[i], or 0 if it isn't one
+func arithmeticEnd(words []parser.Word, i int) int {
+	isOperator := func(j int, op string) bool {
+		return j < len(words) && words[j].Kind == parser.WordOperator && words[j].Value == op
+	}
+	if !isOperator(i+1, "(") {
+		return 0
+	}
+	for j := i + 2; j < len(words); j++ {
+		if words[j].Kind == parser.WordOperator && words[j].Value != ")" {
+			return 0
+		}
+		if isOperator(j, ")") && isOperator(j+1, ")") {
+			return j + 1
+		}
+	}
+	return 0
+}
+
+// readingTest reports whether the commands being read are the test of an if or elif
+func (t *ConditionTracker) readingTest() bool {
+	block := t.top()
+	return block != nil && block.kind == "if" && block.reading
+}
+
+// top returns the innermost open block, or nil
+func (t *ConditionTracker) top() *conditionBlock {
+	if len(t.blocks) == 0 {
+		return nil
+	}
+	return &t.blocks[len(t.blocks)-1]
+}
+
+// pop closes the innermost block if it's of kind
+func (t *ConditionTracker) pop(kind string) {
+	if block := t.top(); block != nil && block.kind == kind {
+		t.blocks = t.blocks[:len(t.blocks)-1]
+	}
+}
+
+// negate returns the negations of tests
+func negate(tests []model.Condition) []model.Condition {
+	negated := make([]model.Condition, len(tests))
+	for i, test := range tests {
+		negated[i] = model.Condition{Text: test.Text, Negated: !test.Negated}
+	}
+	return negated
+}
+
+// rawWords returns the raw text of the literal words of words
+func rawWords(words []parser.Word) []string {
+	raw := make([]string, 0, len(words))
+	for _, w := range words {
+		if w.Kind == parser.WordLiteral {
+			raw = append(raw, w.Raw)
+		}
+	}
+	return raw
+}
+
+// joinConditions returns the conditions of outer followed by inner, or nil if
+// there are none
+func joinConditions(outer, inner []model.Condition) []model.Condition {
+	if len(outer)+len(inner) == 0 {
+		return nil
+	}
+	joined := make([]model.Condition, 0, len(outer)+len(inner))
+	joined = append(joined, outer...)
+	return append(joined, inner...)
+}
@@ -0,0 +1,257 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+	"github.com/oscar.rivas/falias/internal/profile"
+)
+
+func TestConditionTracker(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		dialect parser.Dialect
+		want    map[string]string // Command to the text of its conditions
+	}{
+		{
+			name: "if elif else",
+			lines: []string{
+				`if [[ "$OSTYPE" == darwin* ]]; then`,
+				`  alias ls='ls -G'`,
+				`elif command -v dircolors >/dev/null; then`,
+				`  alias ls='ls --color=auto'`,
+				`else`,
+				`  alias ls='ls -F'`,
+				`fi`,
+				`alias ll='ls -l'`,
+			},
+			want: map[string]string{
+				"alias ls='ls -G'":           `[[ "$OSTYPE" == darwin* ]]`,
+				"alias ls='ls --color=auto'": `! [[ "$OSTYPE" == darwin* ]] && command -v dircolors`,
+				"alias ls='ls -F'":           `! [[ "$OSTYPE" == darwin* ]] && ! command -v dircolors`,
+				"alias ll='ls -l'":           "",
+			},
+		},
+		{
+			name: "one-line if",
+			lines: []string{
+				`if [ -f ~/.work ]; then alias vpn=openconnect; fi`,
+			},
+			want: map[string]string{
+				"alias vpn=openconnect": "[ -f ~/.work ]",
+			},
+		},
+		{
+			name: "case",
+			lines: []string{
+				`case "$(uname)" in`,
+				`  Darwin) alias o=open ;;`,
+				`  Linux|FreeBSD)`,
+				`    alias o=xdg-open`,
+				`    ;;`,
+				`  *) alias o=echo ;;`,
+				`esac`,
+			},
+			want: map[string]string{
+				"alias o=open":     `case "$(uname)" in Darwin`,
+				"alias o=xdg-open": `! case "$(uname)" in Darwin && case "$(uname)" in Linux|FreeBSD`,
+				"alias o=echo":     `! case "$(uname)" in Darwin && ! case "$(uname)" in Linux|FreeBSD`,
+			},
+		},
+		{
+			name: "and-or lists",
+			lines: []string{
+				`command -v bat >/dev/null && alias cat=bat`,
+				`command -v eza >/dev/null || alias eza=ls`,
+			},
+			want: map[string]string{
+				"alias cat=bat": "command -v bat",
+				"alias eza=ls":  "! command -v eza",
+			},
+		},
+		{
+			name: "arithmetic",
+			lines: []string{
+				`if (( $+commands[kubectl] )); then alias k=kubectl; fi`,
+			},
+			want: map[string]string{
+				"alias k=kubectl": "(( $+commands[kubectl] ))",
+			},
+		},
+		{
+			name: "guarded group",
+			lines: []string{
+				`[ -n "$TMUX" ] && {`,
+				`  alias tl='tmux ls'`,
+				`}`,
+				`alias ta='tmux attach'`,
+			},
+			want: map[string]string{
+				"alias tl='tmux ls'":     `[ -n "$TMUX" ]`,
+				"alias ta='tmux attach'": "",
+			},
+		},
+		{
+			name: "nested",
+			lines: []string{
+				`if [ -d ~/bin ]; then`,
+				`  if [ -x ~/bin/kubectl ]; then alias k=kubectl; fi`,
+				`fi`,
+			},
+			want: map[string]string{
+				"alias k=kubectl": "[ -d ~/bin ] && [ -x ~/bin/kubectl ]",
+			},
+		},
+		{
+			name:    "fish if and switch",
+			dialect: parser.DialectFish,
+			lines: []string{
+				`if type -q eza`,
+				`  alias ls=eza`,
+				`else`,
+				`  alias ls='ls -F'`,
+				`end`,
+				`switch (uname)`,
+				`  case Darwin`,
+				`    abbr -a o open`,
+				`end`,
+				`test -n "$TMUX"; and alias tl='tmux ls'`,
+			},
+			want: map[string]string{
+				"alias ls=eza":       "type -q eza",
+				"alias ls='ls -F'":   "! type -q eza",
+				"abbr -a o open":     "case (uname) in Darwin",
+				"alias tl='tmux ls'": `test -n "$TMUX"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewConditionTracker(tt.dialect)
+			got := make(map[string]string)
+			for _, line := range tt.lines {
+				for _, cmd := range tracker.ParseLine(line) {
+					got[cmd.Text()] = model.ConditionText(cmd.Conditions)
+				}
+			}
+
+			for command, want := range tt.want {
+				conditions, ok := got[command]
+				if !ok {
+					t.Errorf("command %q not found in %v", command, got)
+					continue
+				}
+				if conditions != want {
+					t.Errorf("command %q conditions = %q, want %q", command, conditions, want)
+				}
+			}
+			if len(tracker.Conditions()) != 0 {
+				t.Errorf("Conditions() = %v after the last line, want none", tracker.Conditions())
+			}
+		})
+	}
+}
+
+func TestScanConditions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "work.sh", "alias vpn=openconnect\n")
+	rc := writeFile(t, dir, ".bashrc", `if [ "$(hostname)" = work ]; then
+  [ -f `+dir+`/work.sh ] && source `+dir+`/work.sh
+fi
+command -v bat >/dev/null && alias cat=bat
+load_work() {
+  [ -n "$VPN" ] && alias vpnc=vpn
+}
+ll() { alias ll='ls -l'; }
+`)
+
+	result, err := NewScanner().ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		alias string
+		want  string
+	}{
+		{alias: "vpn", want: `[ "$(hostname)" = work ] && [ -f ` + dir + `/work.sh ]`},
+		{alias: "cat", want: "command -v bat"},
+		{alias: "vpnc", want: `[ -n "$VPN" ] && load_work runs`},
+		{alias: "ll", want: "ll runs"},
+	}
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.alias]
+		if !ok {
+			t.Fatalf("alias %q not found", tt.alias)
+		}
+		if got := model.ConditionText(entry.Definitions[0].Conditions); got != tt.want {
+			t.Errorf("alias %q conditions = %q, want %q", tt.alias, got, tt.want)
+		}
+	}
+
+	file := result.Files[dir+"/work.sh"]
+	if file == nil || !file.Conditional || len(file.Conditions) != 2 {
+		t.Errorf("work.sh = %+v, want it conditional under both tests", file)
+	}
+}
+
+func TestScanExcludedConditions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "darwin.sh", "alias o=open\n")
+	rc := writeFile(t, dir, ".bashrc", `alias ls='ls -F'
+if [[ $OSTYPE == darwin* ]]; then
+  alias ls='ls -G'
+  source `+dir+`/darwin.sh
+fi
+command -v podman >/dev/null && alias docker=podman
+command -v kubectl >/dev/null || unalias ls
+command -v eza >/dev/null && alias tree='eza -T'
+kube() {
+  alias k=kubectl
+  command -v podman >/dev/null && alias pm=podman
+}
+`)
+
+	s := NewScanner()
+	s.SetEvaluator(profile.NewEvaluator(&profile.Profile{
+		OS:       "linux",
+		Commands: map[string]bool{"kubectl": true, "podman": false},
+	}))
+	result, err := s.ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		alias        string
+		wantValue    string
+		wantExcluded bool
+	}{
+		{alias: "ls", wantValue: "ls -F"},
+		{alias: "o", wantValue: "open", wantExcluded: true},
+		{alias: "docker", wantValue: "podman", wantExcluded: true},
+		{alias: "tree", wantValue: "eza -T"}, // eza is unknown
+		{alias: "k", wantValue: "kubectl"},   // Whether kube runs is unknown
+		{alias: "pm", wantValue: "podman", wantExcluded: true},
+	}
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.alias]
+		if !ok {
+			t.Fatalf("alias %q not found", tt.alias)
+		}
+		if entry.ActiveValue != tt.wantValue || entry.IsExcluded != tt.wantExcluded || entry.IsRemoved {
+			t.Errorf("alias %q = %q (excluded %v, removed %v), want %q (excluded %v)",
+				tt.alias, entry.ActiveValue, entry.IsExcluded, entry.IsRemoved, tt.wantValue, tt.wantExcluded)
+		}
+	}
+
+	if ls := result.Aliases["ls"]; len(ls.Definitions) != 3 || ls.IsOverridden {
+		t.Errorf("ls definitions = %+v, want the excluded ones kept in the history only", ls.Definitions)
+	}
+	if file := result.Files[dir+"/darwin.sh"]; file == nil || !file.Excluded {
+		t.Errorf("darwin.sh = %+v, want it excluded", file)
+	}
+}
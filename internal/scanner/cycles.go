@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"sort"
+
+	"github.com/oscar.rivas/falias/internal/model"
+)
+
+// detectCycles finds the loops of aliases whose values use each other. Each
+// strongly connected group of two or more aliases in the reference graph is
+// a cycle; an alias using only itself is a harmless self-reference.
+func (s *Scanner) detectCycles(result *model.ScanResult) {
+	expander := NewExpander(result)
+
+	// Reference graph over the aliases the shell expands
+	names := make([]string, 0, len(expander.commands)+len(expander.globals))
+	refs := make(map[string][]string)
+	for _, aliases := range []map[string]*model.AliasEntry{expander.commands, expander.globals} {
+		for name, entry := range aliases {
+			names = append(names, name)
+			refs[name] = expander.References(entry)
+			for _, ref := range refs[name] {
+				if ref == name {
+					entry.SelfReference = true
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+
+	for _, group := range stronglyConnected(names, refs) {
+		if len(group) < 2 {
+			continue
+		}
+
+		inGroup := make(map[string]bool, len(group))
+		for _, name := range group {
+			inGroup[name] = true
+		}
+
+		var cycle model.AliasCycle
+		for _, name := range referenceOrder(group[0], refs, inGroup) {
+			entry := result.Aliases[name]
+			entry.InCycle = true
+			cycle.Members = append(cycle.Members, model.CycleMember{Name: name, Location: entry.ActiveLocation})
+		}
+		result.Cycles = append(result.Cycles, cycle)
+	}
+}
+
+// stronglyConnected returns the strongly connected components of a graph
+// with Tarjan's algorithm, each sorted, in order of their first name
+func stronglyConnected(names []string, refs map[string][]string) [][]string {
+	index := make(map[string]int, len(names))
+	lowlink := make(map[string]int, len(names))
+	onStack := make(map[string]bool, len(names))
+	var stack []string
+	var groups [][]string
+
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		lowlink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, ref := range refs[name] {
+			if _, seen := index[ref]; !seen {
+				visit(ref)
+				lowlink[name] = min(lowlink[name], lowlink[ref])
+			} else if onStack[ref] {
+				lowlink[name] = min(lowlink[name], index[ref])
+			}
+		}
+
+		if lowlink[name] == index[name] {
+			var group []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				group = append(group, top)
+				if top == name {
+					break
+				}
+			}
+			sort.Strings(group)
+			groups = append(groups, group)
+		}
+	}
+
+	for _, name := range names {
+		if _, seen := index[name]; !seen {
+			visit(name)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	return groups
+}
+
+// referenceOrder lists the names of a group as reached by following
+// references from start, so a simple loop reads a → b → c
+func referenceOrder(start string, refs map[string][]string, inGroup map[string]bool) []string {
+	var order []string
+	seen := make(map[string]bool, len(inGroup))
+
+	var visit func(name string)
+	visit = func(name string) {
+		seen[name] = true
+		order = append(order, name)
+		for _, ref := range refs[name] {
+			if inGroup[ref] && !seen[ref] {
+				visit(ref)
+			}
+		}
+	}
+	visit(start)
+	return order
+}
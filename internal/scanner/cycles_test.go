@@ -0,0 +1,80 @@
+package scanner
+
+import "testing"
+
+func TestDetectCycles(t *testing.T) {
+	dir := t.TempDir()
+	other := writeFile(t, dir, "aliases.zsh", "alias z='x -q'\n")
+	zshrc := writeFile(t, dir, ".zshrc", `alias ls='ls --color'
+alias ll='ls -l'
+alias a=b
+alias b=a
+alias x='y && true'
+alias y='z -v'
+alias z='echo x'
+alias sudo='sudo '
+alias p='sudo q'
+alias q=p
+alias -g L='| L'
+alias gone=stale
+alias stale=gone
+unalias stale
+source `+other+`
+`)
+
+	s := NewScanner()
+	result, err := s.ScanShellFiles("zsh", []string{zshrc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	wantCycles := []string{
+		"a → b → a",
+		"p → q → p", // sudo ends in a space, so p uses q
+		"x → y → z → x",
+	}
+	if len(result.Cycles) != len(wantCycles) {
+		t.Fatalf("got %d cycles, want %d: %+v", len(result.Cycles), len(wantCycles), result.Cycles)
+	}
+	for i, want := range wantCycles {
+		if got := result.Cycles[i].Path(); got != want {
+			t.Errorf("cycle %d = %q, want %q", i, got, want)
+		}
+	}
+
+	// Every member carries the location of its active definition
+	for _, member := range result.CycleOf("z").Members {
+		wantFile := zshrc
+		if member.Name == "z" {
+			wantFile = other
+		}
+		if member.Location.FilePath != wantFile || member.Location.LineNum == 0 {
+			t.Errorf("member %q location = %+v, want a line of %s", member.Name, member.Location, wantFile)
+		}
+	}
+
+	tests := []struct {
+		key         string
+		wantSelf    bool
+		wantInCycle bool
+	}{
+		{key: "ls", wantSelf: true},
+		{key: "ll"},
+		{key: "a", wantInCycle: true},
+		{key: "sudo", wantSelf: true},
+		{key: "L", wantSelf: true},
+		{key: "gone"}, // stale was removed
+	}
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.key]
+		if !ok {
+			t.Fatalf("alias %q not found", tt.key)
+		}
+		if entry.SelfReference != tt.wantSelf {
+			t.Errorf("alias %q self reference = %v, want %v", tt.key, entry.SelfReference, tt.wantSelf)
+		}
+		if entry.InCycle != tt.wantInCycle {
+			t.Errorf("alias %q in cycle = %v, want %v", tt.key, entry.InCycle, tt.wantInCycle)
+		}
+	}
+}
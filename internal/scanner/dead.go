@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+// Matches a variable assignment before a command: LANG=C sort
+var assignmentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// precommands run the command that follows them
+var precommands = setOf("!", "builtin", "command", "exec", "noglob", "nocorrect", "time")
+
+// detectDeadAliases flags the aliases whose command can't be found: the first
+// command word of the expanded value is neither a reserved word, builtin,
+// function or other alias from the scan, nor an executable on the assembled
+// PATH. Words the scan can't evaluate, such as $EDITOR, are never flagged.
+func (s *Scanner) detectDeadAliases(result *model.ScanResult) {
+	if len(result.SearchPath) == 0 {
+		return // Every program would look missing
+	}
+
+	dialect := parser.DialectPOSIX
+	if result.Shell == "fish" {
+		dialect = parser.DialectFish
+	}
+
+	for _, entry := range result.Aliases {
+		// Global aliases are fragments of a command line, not commands
+		if entry.IsRemoved || entry.IsExcluded || entry.Type == model.AliasTypeGlobal {
+			continue
+		}
+
+		line := entry.Expanded
+		if entry.Type == model.AliasTypeSuffix {
+			// alias -s md=glow runs glow on the file
+			line = entry.ActiveValue
+		}
+		name, ok := commandWord(line, dialect)
+		if ok && !s.commandExists(name, entry, result) {
+			entry.MissingCommand = name
+		}
+	}
+}
+
+// commandWord returns the command the first simple command of a line runs,
+// skipping variable assignments and precommand modifiers. Returns false if
+// the command can't be known without running the shell.
+func commandWord(line string, dialect parser.Dialect) (string, bool) {
+	words, err := parser.TokenizeDialect(line, dialect)
+	if err != nil {
+		return "", false
+	}
+
+	for _, cmd := range parser.SplitCommands(words) {
+		precommand := false
+		for _, w := range cmd {
+			switch {
+			case w.Kind != parser.WordLiteral:
+				return "", false
+			case precommands[w.Value]:
+				precommand = true
+				continue
+			case precommand && strings.HasPrefix(w.Value, "-"):
+				continue // command -p ls
+			case !precommand && assignmentPattern.MatchString(w.Value):
+				continue
+			case w.Raw != w.Value || strings.ContainsAny(w.Value, "$`*?["):
+				return "", false
+			}
+			return w.Value, true
+		}
+	}
+	return "", false
+}
+
+// commandExists reports whether the shell can run name from alias entry
+func (s *Scanner) commandExists(name string, entry *model.AliasEntry, result *model.ScanResult) bool {
+	if strings.ContainsRune(name, '/') {
+		// Paths are run directly; relative ones depend on the working directory
+		path, ok := s.pathResolver.ResolvePath(name)
+		if !ok || !strings.HasPrefix(path, "/") {
+			return true
+		}
+		info, err := os.Stat(path)
+		return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+	}
+
+	if _, _, ok := ShellCommand(result.Shell, name); ok {
+		return true
+	}
+	if _, ok := result.Functions[name]; ok {
+		return true
+	}
+	// The shell doesn't expand an alias inside its own expansion
+	if other, ok := result.Aliases[name]; ok && other != entry && !other.IsRemoved && !other.IsExcluded {
+		return true
+	}
+	_, found := findExecutable(result.SearchPath, name)
+	// The commands a profile file lists override the scanned PATH
+	if s.evaluator != nil {
+		if installed, listed := s.evaluator.ListedCommand(name); listed {
+			return installed
+		}
+	}
+	return found
+}
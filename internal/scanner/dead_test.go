@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/oscar.rivas/falias/internal/profile"
+)
+
+func TestDetectDeadAliases(t *testing.T) {
+	dir := t.TempDir()
+	writeExecutable(t, dir, "bin/git")
+	writeExecutable(t, dir, "bin/ls")
+	tool := writeExecutable(t, dir, "opt/tool")
+	rc := writeFile(t, dir, ".zshrc", `alias g=git
+alias gs='g status'
+alias ls='ls -G'
+alias k=kubectl
+alias kgp='k get pods'
+alias h='hub browse'
+unalias h
+alias up='cd ..'
+alias e='$EDITOR'
+alias sorted='LANG=C git log'
+alias cmd='command -p exa'
+mkcd() { mkdir -p "$1" && cd "$1"; }
+alias mk=mkcd
+alias t=`+tool+`
+alias gone=`+dir+`/opt/gone
+alias -s md=glow
+alias -g G='| grep'
+alias a=b
+alias b=a
+`)
+
+	s := NewScanner()
+	s.SetEnvironment(map[string]string{"PATH": dir + "/bin"})
+	result, err := s.ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		key         string
+		wantMissing string
+	}{
+		{key: "g"},
+		{key: "gs"},
+		{key: "ls"}, // Uses itself, found on PATH
+		{key: "k", wantMissing: "kubectl"},
+		{key: "kgp", wantMissing: "kubectl"},
+		{key: "h"}, // Removed
+		{key: "up"},
+		{key: "e"}, // Can't be evaluated
+		{key: "sorted"},
+		{key: "cmd", wantMissing: "exa"},
+		{key: "mk"},
+		{key: "t"},
+		{key: "gone", wantMissing: dir + "/opt/gone"},
+		{key: "*.md", wantMissing: "glow"},
+		{key: "G"},
+		{key: "a", wantMissing: "a"}, // Cycles end in a command named after a member
+	}
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.key]
+		if !ok {
+			t.Fatalf("alias %q not found", tt.key)
+		}
+		if entry.MissingCommand != tt.wantMissing {
+			t.Errorf("alias %q missing command = %q, want %q", tt.key, entry.MissingCommand, tt.wantMissing)
+		}
+	}
+}
+
+func TestDetectDeadAliasesOnProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeExecutable(t, dir, "bin/git")
+	writeExecutable(t, dir, "bin/hub")
+	rc := writeFile(t, dir, ".zshrc", `alias g=git
+command -v kubectl >/dev/null && alias k=kubectl
+alias h='hub browse'
+alias gh=gh
+`)
+
+	s := NewScanner()
+	s.SetEnvironment(map[string]string{"PATH": dir + "/bin"})
+	s.SetEvaluator(profile.NewEvaluator(&profile.Profile{
+		OS:       "linux",
+		Commands: map[string]bool{"kubectl": true, "hub": false},
+	}))
+	result, err := s.ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		key         string
+		wantMissing string
+	}{
+		{key: "g"},                     // Unknown to the profile, found on PATH
+		{key: "k"},                     // Installed on the profile
+		{key: "h", wantMissing: "hub"}, // Not installed on the profile
+		{key: "gh", wantMissing: "gh"},
+	}
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.key]
+		if !ok {
+			t.Fatalf("alias %q not found", tt.key)
+		}
+		if entry.MissingCommand != tt.wantMissing {
+			t.Errorf("alias %q missing command = %q, want %q", tt.key, entry.MissingCommand, tt.wantMissing)
+		}
+	}
+}
+
+func TestDetectDeadAliasesOnLocalProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeExecutable(t, dir, "tools/mytoolzz")
+	rc := writeFile(t, dir, ".zshrc", `export PATH=`+dir+`/tools:$PATH
+alias m=mytoolzz
+alias n=nosuchtoolzz
+`)
+
+	s := NewScanner()
+	s.SetEnvironment(map[string]string{"PATH": dir + "/bin"})
+	s.SetEvaluator(profile.NewEvaluator(profile.Local{}))
+	result, err := s.ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	// This machine's PATH doesn't decide, the scanned one does
+	if got := result.Aliases["m"].MissingCommand; got != "" {
+		t.Errorf("alias m missing command = %q, want it found on the scanned PATH", got)
+	}
+	if got := result.Aliases["n"].MissingCommand; got != "nosuchtoolzz" {
+		t.Errorf("alias n missing command = %q, want nosuchtoolzz", got)
+	}
+}
@@ -0,0 +1,209 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+// generators maps the tools whose output shell configs commonly evaluate to
+// the aliases their generated code is documented to define, given the
+// arguments of the command. Tools listed with nil define none.
+var generators = map[string]func(args []string) []string{
+	// thefuck --alias [name]
+	"thefuck": func(args []string) []string {
+		for i, arg := range args {
+			if arg != "--alias" {
+				continue
+			}
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				return []string{args[i+1]}
+			}
+			return []string{"fuck"}
+		}
+		return nil
+	},
+	// zoxide init <shell> [--cmd name] [--no-cmd]
+	"zoxide": func(args []string) []string {
+		cmd := "z"
+		for i, arg := range args {
+			switch {
+			case arg == "--no-cmd":
+				return nil
+			case arg == "--cmd" && i+1 < len(args):
+				cmd = args[i+1]
+			case strings.HasPrefix(arg, "--cmd="):
+				cmd = strings.TrimPrefix(arg, "--cmd=")
+			}
+		}
+		return []string{cmd, cmd + "i"}
+	},
+	// hub alias [-s]
+	"hub": func(args []string) []string {
+		if len(args) > 0 && args[0] == "alias" {
+			return []string{"git"}
+		}
+		return nil
+	},
+	// fasd --init auto
+	"fasd": func(args []string) []string {
+		return []string{"a", "s", "d", "f", "sd", "sf", "z", "zz"}
+	},
+	// gh copilot alias
+	"gh": func(args []string) []string {
+		if len(args) > 1 && args[0] == "copilot" && args[1] == "alias" {
+			return []string{"ghcs", "ghce"}
+		}
+		return nil
+	},
+	// jump shell
+	"jump": func(args []string) []string {
+		return []string{"j"}
+	},
+
+	// Environment, prompt and history hooks
+	"atuin":      nil,
+	"brew":       nil,
+	"direnv":     nil,
+	"dircolors":  nil,
+	"fnm":        nil,
+	"fzf":        nil,
+	"mcfly":      nil,
+	"mise":       nil,
+	"nodenv":     nil,
+	"oh-my-posh": nil,
+	"pyenv":      nil,
+	"rbenv":      nil,
+	"sheldon":    nil,
+	"ssh-agent":  nil,
+	"starship":   nil,
+}
+
+// commandPrefixes are the keywords that may precede a command on the same line
+var commandPrefixes = map[string]bool{
+	"then": true, "else": true, "do": true, "{": true, "!": true,
+	"and": true, "or": true, "not": true, // fish
+}
+
+// ParseDynamicIncludes returns the commands whose output a logical line
+// evaluates as shell code:
+//
+//	eval "$(zoxide init zsh)"
+//	eval `hub alias -s`
+//	source <(kubectl completion zsh)
+//	thefuck --alias | source     (fish)
+//	eval (thefuck --alias)       (fish)
+//
+// Nothing is run; known tools are annotated with the aliases they define.
+func ParseDynamicIncludes(line string, dialect parser.Dialect, location model.SourceLocation) []model.DynamicInclude {
+	includes := make([]model.DynamicInclude, 0)
+
+	words, _ := parser.TokenizeDialect(strings.TrimSpace(line), dialect)
+	start := 0 // Index of the first word of the current command
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+
+		switch {
+		case w.Kind == parser.WordOperator:
+			// fish: cmd | source
+			if w.Value == "|" && i+1 < len(words) && isSourceWord(words[i+1]) && i > start {
+				includes = append(includes, dynamicInclude(joinRaw(words[start:i]), dialect, location))
+			}
+			start = i + 1
+
+		case i == start && w.Kind == parser.WordLiteral && commandPrefixes[w.Raw]:
+			// then eval ..., fish's and eval ...
+			start = i + 1
+
+		case i == start && w.Value == "eval" && i+1 < len(words):
+			// eval "$(cmd)", eval `cmd` or fish's eval (cmd)
+			if command, ok := substitutedCommand(words[i+1].Value); ok {
+				includes = append(includes, dynamicInclude(command, dialect, location))
+			}
+
+		case i == start && isSourceWord(w) && i+2 < len(words) &&
+			words[i+1].Kind == parser.WordRedirect && words[i+1].Value == "<" && words[i+2].Value == "(":
+			// source <(cmd)
+			end := i + 3
+			for end < len(words) && !(words[end].Kind == parser.WordOperator && words[end].Value == ")") {
+				end++
+			}
+			includes = append(includes, dynamicInclude(joinRaw(words[i+3:end]), dialect, location))
+			i = end
+		}
+	}
+
+	return includes
+}
+
+// dynamicInclude describes the command of a dynamic include
+func dynamicInclude(command string, dialect parser.Dialect, location model.SourceLocation) model.DynamicInclude {
+	include := model.DynamicInclude{
+		Command:  command,
+		Location: location,
+	}
+
+	words, _ := parser.TokenizeDialect(command, dialect)
+	commands := parser.SplitCommands(words)
+	if len(commands) == 0 {
+		return include
+	}
+
+	// Skip variable prefixes and the command builtin
+	args := commands[0]
+	for len(args) > 0 && (strings.Contains(args[0].Raw, "=") || args[0].Value == "command") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return include
+	}
+
+	include.Tool = filepath.Base(args[0].Value)
+	values := make([]string, 0, len(args)-1)
+	for _, w := range args[1:] {
+		values = append(values, w.Value)
+	}
+
+	if generator, known := generators[include.Tool]; known {
+		include.Known = true
+		if generator != nil {
+			include.Aliases = generator(values)
+		}
+	} else if len(values) > 0 && (values[0] == "completion" || values[0] == "completions") {
+		// kubectl completion zsh, helm completion bash, rustup completions zsh...
+		include.Known = true
+	}
+
+	return include
+}
+
+// substitutedCommand returns the command of a command substitution word:
+// $(cmd), `cmd` or fish's (cmd)
+func substitutedCommand(word string) (string, bool) {
+	switch {
+	case strings.HasPrefix(word, "$(") && strings.HasSuffix(word, ")"):
+		return strings.TrimSpace(word[2 : len(word)-1]), true
+	case len(word) > 1 && strings.HasPrefix(word, "`") && strings.HasSuffix(word, "`"):
+		return strings.TrimSpace(word[1 : len(word)-1]), true
+	case strings.HasPrefix(word, "(") && strings.HasSuffix(word, ")"):
+		return strings.TrimSpace(word[1 : len(word)-1]), true
+	default:
+		return "", false
+	}
+}
+
+// isSourceWord reports whether a word is the source builtin
+func isSourceWord(w parser.Word) bool {
+	return w.Kind == parser.WordLiteral && (w.Value == "source" || w.Value == ".")
+}
+
+// joinRaw joins the raw text of words with spaces
+func joinRaw(words []parser.Word) string {
+	raw := make([]string, 0, len(words))
+	for _, w := range words {
+		raw = append(raw, w.Raw)
+	}
+	return strings.Join(raw, " ")
+}
@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+func TestParseDynamicIncludes(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		dialect     parser.Dialect
+		wantCommand string
+		wantTool    string
+		wantKnown   bool
+		wantAliases []string
+	}{
+		{
+			name:        "eval command substitution",
+			line:        `eval "$(thefuck --alias)"`,
+			wantCommand: "thefuck --alias",
+			wantTool:    "thefuck",
+			wantKnown:   true,
+			wantAliases: []string{"fuck"},
+		},
+		{
+			name:        "custom alias name",
+			line:        `eval "$(thefuck --alias f)"`,
+			wantCommand: "thefuck --alias f",
+			wantTool:    "thefuck",
+			wantKnown:   true,
+			wantAliases: []string{"f"},
+		},
+		{
+			name:        "guarded zoxide with --cmd",
+			line:        `command -v zoxide >/dev/null && eval "$(zoxide init zsh --cmd j)"`,
+			wantCommand: "zoxide init zsh --cmd j",
+			wantTool:    "zoxide",
+			wantKnown:   true,
+			wantAliases: []string{"j", "ji"},
+		},
+		{
+			name:        "backquotes",
+			line:        "eval `hub alias -s`",
+			wantCommand: "hub alias -s",
+			wantTool:    "hub",
+			wantKnown:   true,
+			wantAliases: []string{"git"},
+		},
+		{
+			name:        "process substitution",
+			line:        "source <(kubectl completion zsh)",
+			wantCommand: "kubectl completion zsh",
+			wantTool:    "kubectl",
+			wantKnown:   true,
+		},
+		{
+			name:        "after then",
+			line:        `if [ -x /opt/homebrew/bin/brew ]; then eval "$(/opt/homebrew/bin/brew shellenv)"; fi`,
+			wantCommand: "/opt/homebrew/bin/brew shellenv",
+			wantTool:    "brew",
+			wantKnown:   true,
+		},
+		{
+			name:        "unknown tool",
+			line:        `eval "$(mytool setup)"`,
+			wantCommand: "mytool setup",
+			wantTool:    "mytool",
+		},
+		{
+			name:        "fish pipe to source",
+			line:        "zoxide init fish | source",
+			dialect:     parser.DialectFish,
+			wantCommand: "zoxide init fish",
+			wantTool:    "zoxide",
+			wantKnown:   true,
+			wantAliases: []string{"z", "zi"},
+		},
+		{
+			name:        "fish eval",
+			line:        "type -q thefuck; and eval (thefuck --alias)",
+			dialect:     parser.DialectFish,
+			wantCommand: "thefuck --alias",
+			wantTool:    "thefuck",
+			wantKnown:   true,
+			wantAliases: []string{"fuck"},
+		},
+		{
+			name: "eval of a variable",
+			line: `eval "$cmd"`,
+		},
+		{
+			name: "plain source",
+			line: "source ~/.aliases",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			includes := ParseDynamicIncludes(tt.line, tt.dialect, model.SourceLocation{LineNum: 1})
+			if tt.wantCommand == "" {
+				if len(includes) != 0 {
+					t.Errorf("ParseDynamicIncludes() = %+v, want none", includes)
+				}
+				return
+			}
+			if len(includes) != 1 {
+				t.Fatalf("ParseDynamicIncludes() returned %d includes, want 1", len(includes))
+			}
+
+			got := includes[0]
+			if got.Command != tt.wantCommand || got.Tool != tt.wantTool || got.Known != tt.wantKnown {
+				t.Errorf("ParseDynamicIncludes() = %q (tool %q, known %v), want %q (tool %q, known %v)",
+					got.Command, got.Tool, got.Known, tt.wantCommand, tt.wantTool, tt.wantKnown)
+			}
+			if strings.Join(got.Aliases, ",") != strings.Join(tt.wantAliases, ",") {
+				t.Errorf("ParseDynamicIncludes() aliases = %v, want %v", got.Aliases, tt.wantAliases)
+			}
+		})
+	}
+}
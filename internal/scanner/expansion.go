@@ -0,0 +1,212 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+const (
+	maxExpansionSteps = 100 // Upper bound on substitutions, far above any real chain
+)
+
+// expansionWord is a word of a command line being expanded
+type expansionWord struct {
+	parser.Word
+	space   string          // Text separating the word from the previous one, as written
+	command bool            // In command position, or following an alias ending in a blank
+	active  map[string]bool // Aliases whose expansion produced the word, which it can't expand again
+}
+
+// Expander expands aliases the way the shell does when running a command line:
+//
+//   - the first word of each simple command is replaced by its alias value,
+//     which is expanded again, except for aliases already being expanded,
+//     so alias ls='ls -G' stops after one step
+//   - in bash and zsh, the word after an alias whose value ends in a blank is
+//     expanded too: alias sudo='sudo '
+//   - zsh global aliases are expanded anywhere in the line
+//
+// Quoted or escaped words are never expanded. fish aliases are functions;
+// running one runs its body, so their first words chain the same way.
+type Expander struct {
+	shell    string
+	commands map[string]*model.AliasEntry // Aliases expanded in command position
+	globals  map[string]*model.AliasEntry // zsh global aliases
+}
+
+// NewExpander creates an expander for the active aliases of a scan
+func NewExpander(result *model.ScanResult) *Expander {
+	x := &Expander{
+		shell:    result.Shell,
+		commands: make(map[string]*model.AliasEntry),
+		globals:  make(map[string]*model.AliasEntry),
+	}
+
+	for _, entry := range result.Aliases {
+		if entry.IsRemoved || entry.IsExcluded {
+			continue
+		}
+		switch entry.Type {
+		case model.AliasTypeNormal:
+			x.commands[entry.Name] = entry
+		case model.AliasTypeGlobal:
+			if x.shell == "zsh" {
+				x.globals[entry.Name] = entry
+			}
+		}
+	}
+	return x
+}
+
+// Expand returns the expansion of an alias step by step, starting with the
+// alias' own value. The last step is the fully expanded command.
+func (x *Expander) Expand(entry *model.AliasEntry) []model.ExpansionStep {
+	steps := []model.ExpansionStep{{Alias: entry.Name, Text: entry.ActiveValue}}
+	words := x.substitute(entry.ActiveValue, true, map[string]bool{entry.Name: true})
+
+	for len(steps) < maxExpansionSteps {
+		i, alias := x.next(words)
+		if alias == nil {
+			break
+		}
+
+		replacement := x.substitute(alias.ActiveValue, words[i].command, with(words[i].active, alias.Name))
+		if len(replacement) > 0 {
+			// The value takes the place of the alias, after the same separator
+			replacement[0].space = words[i].space
+		}
+		// An alias ending in a blank makes the next word eligible as well
+		if words[i].command && x.shell != "fish" && endsInBlank(alias.ActiveValue) {
+			if i+1 < len(words) && words[i+1].Kind == parser.WordLiteral {
+				words[i+1].command = true
+			}
+		}
+
+		words = append(words[:i], append(replacement, words[i+1:]...)...)
+		steps = append(steps, model.ExpansionStep{Alias: alias.Name, Text: joinExpansion(words)})
+	}
+
+	return steps
+}
+
+// References returns the aliases the value of entry uses directly, in order,
+// including entry itself. Unlike Expand it doesn't stop at aliases already
+// being expanded.
+func (x *Expander) References(entry *model.AliasEntry) []string {
+	words := x.substitute(entry.ActiveValue, true, nil)
+
+	var names []string
+	for i, w := range words {
+		if w.Kind != parser.WordLiteral || w.Raw != w.Value {
+			continue
+		}
+		alias, ok := x.commands[w.Value]
+		if !ok || !w.command {
+			if alias, ok = x.globals[w.Value]; !ok {
+				continue
+			}
+		}
+
+		names = append(names, alias.Name)
+		if w.command && x.shell != "fish" && endsInBlank(alias.ActiveValue) && i+1 < len(words) && words[i+1].Kind == parser.WordLiteral {
+			words[i+1].command = true
+		}
+	}
+	return names
+}
+
+// next returns the first word that expands and its alias, or nil if none does
+func (x *Expander) next(words []expansionWord) (int, *model.AliasEntry) {
+	for i, w := range words {
+		// Quoted, escaped and substituted words are never expanded
+		if w.Kind != parser.WordLiteral || w.Raw != w.Value || w.active[w.Value] {
+			continue
+		}
+		if alias, ok := x.commands[w.Value]; ok && w.command {
+			return i, alias
+		}
+		if alias, ok := x.globals[w.Value]; ok {
+			return i, alias
+		}
+	}
+	return 0, nil
+}
+
+// substitute tokenizes an alias value into the words replacing the alias.
+// command tells whether the alias was in command position, and active lists
+// the aliases being expanded.
+func (x *Expander) substitute(value string, command bool, active map[string]bool) []expansionWord {
+	dialect := parser.DialectPOSIX
+	if x.shell == "fish" {
+		dialect = parser.DialectFish
+	}
+	tokens, _ := parser.TokenizeDialect(value, dialect)
+
+	words := make([]expansionWord, 0, len(tokens))
+	pos := 0
+	for _, token := range tokens {
+		// Keep the blanks, newlines and comments before the word as written
+		space := ""
+		if start := strings.Index(value[pos:], token.Raw); start >= 0 {
+			space = value[pos : pos+start]
+			pos += start + len(token.Raw)
+		}
+		words = append(words, expansionWord{Word: token, space: space, command: command, active: active})
+
+		switch {
+		case token.Kind == parser.WordOperator:
+			// Each command of a list or pipeline starts in command position
+			command = true
+		case token.Kind == parser.WordLiteral && command && commandPrefixes[token.Raw]:
+			// then ls, do ls, ! ls
+		default:
+			command = false
+		}
+	}
+	return words
+}
+
+// endsInBlank reports whether an alias value ends in a space or tab
+func endsInBlank(value string) bool {
+	return strings.HasSuffix(value, " ") || strings.HasSuffix(value, "\t")
+}
+
+// with returns a copy of active with name added
+func with(active map[string]bool, name string) map[string]bool {
+	copied := make(map[string]bool, len(active)+1)
+	for alias := range active {
+		copied[alias] = true
+	}
+	copied[name] = true
+	return copied
+}
+
+// joinExpansion joins the raw text of words with the separators they were
+// written with
+func joinExpansion(words []expansionWord) string {
+	var line strings.Builder
+	for i, w := range words {
+		if i > 0 {
+			line.WriteString(w.space)
+		}
+		line.WriteString(w.Raw)
+	}
+	return line.String()
+}
+
+// expandAliases records the expansion of every alias of the scan
+func (s *Scanner) expandAliases(result *model.ScanResult) {
+	expander := NewExpander(result)
+	for _, entry := range result.Aliases {
+		// Suffix aliases run their value on a file, not as a command line,
+		// and removed aliases never run
+		if entry.Type == model.AliasTypeSuffix || entry.IsRemoved {
+			continue
+		}
+
+		entry.Expansion = expander.Expand(entry)
+		entry.Expanded = entry.Expansion[len(entry.Expansion)-1].Text
+	}
+}
@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandAliases(t *testing.T) {
+	dir := t.TempDir()
+	zshrc := writeFile(t, dir, ".zshrc", `alias g=git
+alias gs='g status'
+alias ls='ls -G'
+alias ll='ls -l'
+alias sudo='sudo '
+alias please='sudo ll'
+alias nocheck='echo ll'
+alias a=b
+alias b=a
+alias -g G='| grep'
+alias logs='journalctl G error'
+alias up='cd .. && ll'
+alias quoted="'ll' -a"
+alias tmp=ls
+alias old=tmp
+unalias tmp
+alias after='old x'
+alias -s md=glow
+alias tabbed=$'g\tlog  -1'
+`)
+
+	s := NewScanner()
+	result, err := s.ScanShellFiles("zsh", []string{zshrc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		key      string
+		wantText []string // Command line after each step
+	}{
+		{key: "g", wantText: []string{"git"}},
+		{key: "gs", wantText: []string{"g status", "git status"}},
+		{key: "ls", wantText: []string{"ls -G"}},
+		{key: "please", wantText: []string{"sudo ll", "sudo ll", "sudo ls -l", "sudo ls -G -l"}},
+		{key: "nocheck", wantText: []string{"echo ll"}},
+		{key: "a", wantText: []string{"b", "a"}},
+		{key: "logs", wantText: []string{"journalctl G error", "journalctl | grep error"}},
+		{key: "up", wantText: []string{"cd .. && ll", "cd .. && ls -l", "cd .. && ls -G -l"}},
+		{key: "quoted", wantText: []string{"'ll' -a"}},
+		{key: "after", wantText: []string{"old x", "tmp x"}}, // tmp was removed
+		{key: "*.md"},
+		{key: "tabbed", wantText: []string{"g\tlog  -1", "git\tlog  -1"}},
+		{key: "tmp"}, // Removed aliases aren't expanded
+	}
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.key]
+		if !ok {
+			t.Fatalf("alias %q not found", tt.key)
+		}
+
+		var got []string
+		for _, step := range entry.Expansion {
+			got = append(got, step.Text)
+		}
+		if !reflect.DeepEqual(got, tt.wantText) {
+			t.Errorf("alias %q expansion = %q, want %q", tt.key, got, tt.wantText)
+		}
+
+		var wantExpanded string
+		if len(tt.wantText) > 0 {
+			wantExpanded = tt.wantText[len(tt.wantText)-1]
+		}
+		if entry.Expanded != wantExpanded {
+			t.Errorf("alias %q expanded = %q, want %q", tt.key, entry.Expanded, wantExpanded)
+		}
+	}
+
+	// Each step names the alias it substituted
+	steps := result.Aliases["please"].Expansion
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Alias)
+	}
+	if want := []string{"please", "sudo", "ll", "ls"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("please expansion aliases = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,319 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+const (
+	maxContinuationLines = 500 // Give up joining an unclosed quote after this many lines
+)
+
+// LogicalLine is a complete shell statement that may span several physical lines
+type LogicalLine struct {
+	Text      string // Physical lines joined with newlines
+	StartLine int    // First physical line (1-based)
+	EndLine   int    // Last physical line (1-based)
+}
+
+// JoinLogicalLines joins physical lines ending in a backslash continuation or
+// inside an unclosed quoted string into logical lines. Here-document bodies
+// are skipped, since they are data rather than commands.
+// If a quote is never closed, the opening line is kept on its own so that a
+// stray quote cannot swallow the rest of the file.
+func JoinLogicalLines(lines []string) []LogicalLine {
+	return JoinLogicalLinesDialect(lines, parser.DialectPOSIX)
+}
+
+// JoinLogicalLinesDialect joins physical lines into logical lines using the
+// quoting rules of the given shell dialect
+func JoinLogicalLinesDialect(lines []string, dialect parser.Dialect) []LogicalLine {
+	logical := make([]LogicalLine, 0, len(lines))
+	quotes := newQuoteTracker(dialect)
+
+	for i := 0; i < len(lines); i++ {
+		text := lines[i]
+		last := i // Last line of the statement
+		end := i  // Last line consumed, including here-document bodies
+		quotes.ParseLine(lines[i])
+
+		for {
+			for quotes.InHeredoc() && end+1 < len(lines) {
+				end++
+				quotes.ParseLine(lines[end])
+			}
+			if !quotes.Open() && (quotes.InComment() || !hasTrailingBackslash(lines[last])) {
+				break
+			}
+			if end+1 >= len(lines) || end-i >= maxContinuationLines {
+				break
+			}
+			end++
+			last = end
+			text += "\n" + lines[end]
+			quotes.ParseLine(lines[end])
+		}
+
+		if quotes.Open() {
+			// Never closed: fall back to the single physical line
+			text = lines[i]
+			last, end = i, i
+			quotes = newQuoteTracker(dialect)
+		}
+
+		logical = append(logical, LogicalLine{
+			Text:      text,
+			StartLine: i + 1,
+			EndLine:   last + 1,
+		})
+		i = end
+	}
+
+	return logical
+}
+
+// quoteContext is a quote or substitution left open by the lines read so far
+type quoteContext struct {
+	kind  byte // The opening quote, ( for $(...) and (...), { for ${...}, m for $((...)) and ((...)), a for $'...'
+	depth int  // Nesting of the parentheses or braces of a substitution
+}
+
+// heredoc is a here-document whose body follows the line that starts it
+type heredoc struct {
+	delimiter string // Line ending the body
+	stripTabs bool   // <<- ignores leading tabs on the delimiter line
+}
+
+// quoteTracker follows the quotes, substitutions and here-documents of a file
+// line by line, so that joining a statement reads each line once instead of
+// tokenizing the whole statement again for every line added. It follows the
+// quoting rules of the tokenizer.
+type quoteTracker struct {
+	fish     bool
+	open     []quoteContext // Open quotes and substitutions, innermost last
+	heredocs []heredoc      // Here-documents started on the current line
+	bodies   []heredoc      // Here-documents whose bodies are being read, in order
+	comment  bool           // The last line scanned ends in a comment
+}
+
+// newQuoteTracker creates a new quote tracker for a dialect
+func newQuoteTracker(dialect parser.Dialect) *quoteTracker {
+	return &quoteTracker{fish: dialect == parser.DialectFish}
+}
+
+// Open reports whether the lines read so far end inside a quote or substitution
+func (q *quoteTracker) Open() bool {
+	return len(q.open) > 0
+}
+
+// InComment reports whether the last line read outside a here-document ends
+// in a comment, where a trailing backslash doesn't continue the line
+func (q *quoteTracker) InComment() bool {
+	return q.comment
+}
+
+// InHeredoc reports whether the next line belongs to a here-document body
+func (q *quoteTracker) InHeredoc() bool {
+	return len(q.bodies) > 0
+}
+
+// ParseLine feeds the next physical line
+func (q *quoteTracker) ParseLine(line string) {
+	if len(q.bodies) > 0 {
+		body := q.bodies[0]
+		if body.stripTabs {
+			line = strings.TrimLeft(line, "\t")
+		}
+		if line == body.delimiter {
+			q.bodies = q.bodies[1:]
+		}
+		return
+	}
+
+	q.scan(line)
+
+	// Bodies start on the line after the one starting them
+	q.bodies = append(q.bodies, q.heredocs...)
+	q.heredocs = nil
+}
+
+// scan reads the quotes and substitutions of a line
+func (q *quoteTracker) scan(line string) {
+	q.comment = false
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+
+		if len(q.open) == 0 {
+			switch {
+			case ch == '\\':
+				i++
+			case ch == '#' && wordStart(line, i):
+				q.comment = true
+				return
+			case ch == '\'', ch == '"', ch == '`' && !q.fish:
+				q.push(ch, 0)
+			case ch == '$':
+				i = q.dollar(line, i, false)
+			case ch == '(' && !q.fish && wordStart(line, i):
+				// Operator, unless it starts an arithmetic command
+				if strings.HasPrefix(line[i:], "((") {
+					q.push('m', 2)
+					i++
+				}
+			case ch == '(':
+				// Glob qualifier or fish command substitution
+				q.push('(', 1)
+			case ch == '<' && !q.fish:
+				i = q.heredoc(line, i)
+			}
+			continue
+		}
+
+		top := &q.open[len(q.open)-1]
+		switch top.kind {
+		case '\'':
+			if ch == '\\' && q.fish && i+1 < len(line) && (line[i+1] == '\'' || line[i+1] == '\\') {
+				i++
+			} else if ch == '\'' {
+				q.pop()
+			}
+		case 'a':
+			if ch == '\\' {
+				i++
+			} else if ch == '\'' {
+				q.pop()
+			}
+		case '`':
+			if ch == '\\' {
+				i++
+			} else if ch == '`' {
+				q.pop()
+			}
+		case '"':
+			switch {
+			case ch == '\\':
+				i++
+			case ch == '"':
+				q.pop()
+			case ch == '`' && !q.fish:
+				q.push(ch, 0)
+			case ch == '$':
+				i = q.dollar(line, i, true)
+			}
+		default:
+			open, close := byte('('), byte(')')
+			if top.kind == '{' {
+				open, close = '{', '}'
+			}
+			switch {
+			case ch == '\\':
+				i++
+			case ch == '#' && wordStart(line, i):
+				// Comment, which may hold a stray quote
+				q.comment = true
+				return
+			case ch == open:
+				top.depth++
+			case ch == close:
+				if top.depth--; top.depth == 0 {
+					q.pop()
+				}
+			case ch == '\'', ch == '"', ch == '`' && !q.fish:
+				q.push(ch, 0)
+			case ch == '<' && top.kind == '(' && !q.fish:
+				i = q.heredoc(line, i)
+			}
+		}
+	}
+}
+
+// dollar reads the expansion starting at line[i] and returns the index of its
+// last character read
+func (q *quoteTracker) dollar(line string, i int, quoted bool) int {
+	rest := line[i:]
+	switch {
+	case strings.HasPrefix(rest, "$'") && !quoted && !q.fish:
+		q.push('a', 0)
+		return i + 1
+	case strings.HasPrefix(rest, "$(("):
+		q.push('m', 2)
+		return i + 2
+	case strings.HasPrefix(rest, "$("):
+		q.push('(', 1)
+		return i + 1
+	case strings.HasPrefix(rest, "${"):
+		q.push('{', 1)
+		return i + 1
+	}
+	return i
+}
+
+// heredoc records the here-document whose << operator is at line[i], and
+// returns the index of the last character of its delimiter word
+func (q *quoteTracker) heredoc(line string, i int) int {
+	if !strings.HasPrefix(line[i:], "<<") || strings.HasPrefix(line[i:], "<<<") {
+		return i
+	}
+
+	doc := heredoc{}
+	j := i + 2
+	if j < len(line) && line[j] == '-' {
+		doc.stripTabs = true
+		j++
+	}
+	for j < len(line) && (line[j] == ' ' || line[j] == '\t') {
+		j++
+	}
+
+	// The delimiter is the word after the operator with its quotes removed
+	var delimiter strings.Builder
+	for ; j < len(line) && !strings.ContainsRune(" \t;&|<>()", rune(line[j])); j++ {
+		switch ch := line[j]; ch {
+		case '\'', '"':
+			end := strings.IndexByte(line[j+1:], ch)
+			if end < 0 {
+				return j - 1
+			}
+			delimiter.WriteString(line[j+1 : j+1+end])
+			j += end + 1
+		case '\\':
+			if j+1 < len(line) {
+				j++
+				delimiter.WriteByte(line[j])
+			}
+		default:
+			delimiter.WriteByte(ch)
+		}
+	}
+
+	if delimiter.Len() > 0 {
+		doc.delimiter = delimiter.String()
+		q.heredocs = append(q.heredocs, doc)
+	}
+	return j - 1
+}
+
+// push opens a quote or substitution
+func (q *quoteTracker) push(kind byte, depth int) {
+	q.open = append(q.open, quoteContext{kind: kind, depth: depth})
+}
+
+// pop closes the innermost quote or substitution
+func (q *quoteTracker) pop() {
+	q.open = q.open[:len(q.open)-1]
+}
+
+// wordStart reports whether line[i] starts a word
+func wordStart(line string, i int) bool {
+	return i == 0 || strings.IndexByte(" \t;&|()<>", line[i-1]) >= 0
+}
+
+// hasTrailingBackslash reports whether text ends in an unescaped backslash
+func hasTrailingBackslash(text string) bool {
+	count := 0
+	for i := len(text) - 1; i >= 0 && text[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
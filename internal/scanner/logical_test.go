@@ -0,0 +1,112 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestJoinLogicalLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []string
+		wantTexts []string
+		wantSpans [][2]int
+	}{
+		{
+			name:      "independent lines",
+			lines:     []string{"alias a=b", "alias c=d"},
+			wantTexts: []string{"alias a=b", "alias c=d"},
+			wantSpans: [][2]int{{1, 1}, {2, 2}},
+		},
+		{
+			name:      "backslash continuation",
+			lines:     []string{"alias gl='git log' \\", "  gs='git status'", "echo done"},
+			wantTexts: []string{"alias gl='git log' \\\n  gs='git status'", "echo done"},
+			wantSpans: [][2]int{{1, 2}, {3, 3}},
+		},
+		{
+			name:      "open single quote",
+			lines:     []string{"alias deploy='make build &&", "  make push'", "alias x=y"},
+			wantTexts: []string{"alias deploy='make build &&\n  make push'", "alias x=y"},
+			wantSpans: [][2]int{{1, 2}, {3, 3}},
+		},
+		{
+			name:      "escaped backslash does not continue",
+			lines:     []string{`alias bs=\\`, "alias x=y"},
+			wantTexts: []string{`alias bs=\\`, "alias x=y"},
+			wantSpans: [][2]int{{1, 1}, {2, 2}},
+		},
+		{
+			name:      "comment ending in backslash",
+			lines:     []string{`# C:\`, "alias x=y"},
+			wantTexts: []string{`# C:\`, "alias x=y"},
+			wantSpans: [][2]int{{1, 1}, {2, 2}},
+		},
+		{
+			name:      "inline comment ending in backslash",
+			lines:     []string{`alias a1=1 # note \`, "alias a2=1"},
+			wantTexts: []string{`alias a1=1 # note \`, "alias a2=1"},
+			wantSpans: [][2]int{{1, 1}, {2, 2}},
+		},
+		{
+			name:      "heredoc body",
+			lines:     []string{"cat <<EOF > ~/.note", "don't forget", "alias inside=body", "EOF", "alias x=y"},
+			wantTexts: []string{"cat <<EOF > ~/.note", "alias x=y"},
+			wantSpans: [][2]int{{1, 1}, {5, 5}},
+		},
+		{
+			name:      "quoted heredoc delimiter with tabs stripped",
+			lines:     []string{"cat <<-'END' | sh", "\tit's $HOME", "\tEND", "alias x=y"},
+			wantTexts: []string{"cat <<-'END' | sh", "alias x=y"},
+			wantSpans: [][2]int{{1, 1}, {4, 4}},
+		},
+		{
+			name:      "heredoc in a substitution",
+			lines:     []string{"msg=$(cat <<EOF", "won't", "EOF", ")", "alias x=y"},
+			wantTexts: []string{"msg=$(cat <<EOF\n)", "alias x=y"},
+			wantSpans: [][2]int{{1, 4}, {5, 5}},
+		},
+		{
+			name:      "arithmetic shift is not a heredoc",
+			lines:     []string{"n=$((1<<2))", "alias x=y"},
+			wantTexts: []string{"n=$((1<<2))", "alias x=y"},
+			wantSpans: [][2]int{{1, 1}, {2, 2}},
+		},
+		{
+			name:      "open substitution",
+			lines:     []string{`eval "$(printf '%s' "a`, `b")"`, "alias x=y"},
+			wantTexts: []string{"eval \"$(printf '%s' \"a\nb\")\"", "alias x=y"},
+			wantSpans: [][2]int{{1, 2}, {3, 3}},
+		},
+		{
+			name:      "apostrophe in a comment inside an array",
+			lines:     []string{"plugins=(", "  git   # it's great", "  mine", ")", "alias x=y"},
+			wantTexts: []string{"plugins=(\n  git   # it's great\n  mine\n)", "alias x=y"},
+			wantSpans: [][2]int{{1, 4}, {5, 5}},
+		},
+		{
+			name:      "quote never closed",
+			lines:     []string{"alias broken='oops", "alias x=y"},
+			wantTexts: []string{"alias broken='oops", "alias x=y"},
+			wantSpans: [][2]int{{1, 1}, {2, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := JoinLogicalLines(tt.lines)
+
+			if len(got) != len(tt.wantTexts) {
+				t.Fatalf("JoinLogicalLines() returned %d lines, want %d: %+v", len(got), len(tt.wantTexts), got)
+			}
+
+			for i, line := range got {
+				if line.Text != tt.wantTexts[i] {
+					t.Errorf("JoinLogicalLines()[%d].Text = %q, want %q", i, line.Text, tt.wantTexts[i])
+				}
+				if line.StartLine != tt.wantSpans[i][0] || line.EndLine != tt.wantSpans[i][1] {
+					t.Errorf("JoinLogicalLines()[%d] span = %d-%d, want %d-%d", i, line.StartLine, line.EndLine, tt.wantSpans[i][0], tt.wantSpans[i][1])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+var (
+	// Matches a word that is nothing but a variable reference: $f or ${f}
+	loopVarRefPattern = regexp.MustCompile(`^\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?$`)
+
+	// Matches a zsh glob qualifier such as (N) or (.N) ending a pattern
+	globQualifierPattern = regexp.MustCompile(`\([^()/]*\)$`)
+)
+
+// LoopInclude is a file sourced through the variable of a for loop, as in
+// for f in ~/.aliases.d/*.sh; do source "$f"; done
+type LoopInclude struct {
+	Variable string   // Loop variable passed to source
+	Patterns []string // Words the loop iterates over, unexpanded
+}
+
+// forLoop is a loop whose body is still open
+type forLoop struct {
+	variable string   // Loop variable, empty for while, until and arithmetic loops
+	words    []string // Words the loop iterates over
+	short    bool     // zsh short form for f (words) cmd, ends with the line unless do follows
+}
+
+// LoopTracker follows the loops of a file so that sourcing a loop variable can
+// be expanded to the files the loop iterates over. Loops span several lines,
+// so the tracker must be fed every logical line of a file in order.
+type LoopTracker struct {
+	fish   bool
+	loops  []forLoop // Open loops, innermost last
+	blocks []string  // fish: kinds of the open blocks, all closed by end, innermost last
+}
+
+// NewLoopTracker creates a new loop tracker for a dialect
+func NewLoopTracker(dialect parser.Dialect) *LoopTracker {
+	return &LoopTracker{fish: dialect == parser.DialectFish}
+}
+
+// ParseLine feeds the next logical line and returns the loop variables sourced on it
+func (t *LoopTracker) ParseLine(line string) []LoopInclude {
+	var includes []LoopInclude
+
+	dialect := parser.DialectPOSIX
+	if t.fish {
+		dialect = parser.DialectFish
+	}
+	words, _ := parser.TokenizeDialect(line, dialect)
+	cmdStart := true
+
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+
+		if w.Kind == parser.WordOperator {
+			cmdStart = w.Value != ")"
+			continue
+		}
+
+		if !cmdStart || w.Kind != parser.WordLiteral || w.Raw != w.Value {
+			cmdStart = false
+			continue
+		}
+		cmdStart = false
+
+		switch w.Value {
+		case "for":
+			i = t.openFor(words, i)
+			t.openBlock("loop")
+			// The body of a short form loop follows its closing parenthesis
+			cmdStart = t.loops[len(t.loops)-1].short
+		case "while", "until", "select":
+			t.loops = append(t.loops, forLoop{})
+			t.openBlock("loop")
+		case "if", "function", "switch", "begin":
+			// fish closes these with end too, so they must be told apart from loops
+			if t.fish {
+				t.openBlock(w.Value)
+				cmdStart = w.Value != "function" && w.Value != "switch"
+			}
+		case "do":
+			if n := len(t.loops); n > 0 {
+				t.loops[n-1].short = false
+			}
+			cmdStart = true
+		case "done":
+			if !t.fish {
+				t.closeLoop()
+			}
+		case "end":
+			// fish closes every block with end, only the end of a loop closes it
+			if n := len(t.blocks); t.fish && n > 0 {
+				if t.blocks[n-1] == "loop" {
+					t.closeLoop()
+				}
+				t.blocks = t.blocks[:n-1]
+			}
+		case "then", "else", "elif", "{", "!":
+			cmdStart = true
+		case "source", ".":
+			if i+1 >= len(words) || words[i+1].Kind != parser.WordLiteral {
+				break
+			}
+			if loop, ok := t.loopFor(words[i+1].Value); ok {
+				includes = append(includes, LoopInclude{
+					Variable: loop.variable,
+					Patterns: loop.words,
+				})
+			}
+		}
+	}
+
+	// A zsh short form loop without do ends with its command
+	for len(t.loops) > 0 && t.loops[len(t.loops)-1].short {
+		t.loops = t.loops[:len(t.loops)-1]
+	}
+
+	return includes
+}
+
+// openBlock records a fish block closed by end
+func (t *LoopTracker) openBlock(kind string) {
+	if t.fish {
+		t.blocks = append(t.blocks, kind)
+	}
+}
+
+// closeLoop drops the innermost open loop
+func (t *LoopTracker) closeLoop() {
+	if n := len(t.loops); n > 0 {
+		t.loops = t.loops[:n-1]
+	}
+}
+
+// openFor records the loop whose header starts at words[i] and returns the
+// index of the last word of the header
+func (t *LoopTracker) openFor(words []parser.Word, i int) int {
+	loop := forLoop{}
+
+	if i+1 < len(words) && words[i+1].Kind == parser.WordLiteral {
+		loop.variable = words[i+1].Value
+		i++
+
+		switch {
+		case i+1 < len(words) && words[i+1].Value == "in" && words[i+1].Kind == parser.WordLiteral:
+			i++
+			for i+1 < len(words) && words[i+1].Kind == parser.WordLiteral {
+				loop.words = append(loop.words, words[i+1].Value)
+				i++
+			}
+		case i+1 < len(words) && words[i+1].Kind == parser.WordOperator && words[i+1].Value == "(":
+			// zsh: for f (words) cmd
+			i++
+			for i+1 < len(words) && words[i+1].Kind == parser.WordLiteral {
+				loop.words = append(loop.words, words[i+1].Value)
+				i++
+			}
+			if i+1 < len(words) && words[i+1].Value == ")" {
+				i++
+			}
+			loop.short = true
+		}
+	}
+
+	t.loops = append(t.loops, loop)
+	return i
+}
+
+// loopFor returns the innermost open loop whose variable the word refers to
+func (t *LoopTracker) loopFor(word string) (forLoop, bool) {
+	matches := loopVarRefPattern.FindStringSubmatch(word)
+	if matches == nil {
+		return forLoop{}, false
+	}
+
+	for i := len(t.loops) - 1; i >= 0; i-- {
+		if t.loops[i].variable == matches[1] {
+			return t.loops[i], true
+		}
+	}
+	return forLoop{}, false
+}
+
+// refersToVariable reports whether a raw include path is a reference to variable
+func refersToVariable(path string, variable string) bool {
+	words, _ := parser.Tokenize(path)
+	if len(words) == 0 {
+		return false
+	}
+	matches := loopVarRefPattern.FindStringSubmatch(words[0].Value)
+	return matches != nil && matches[1] == variable
+}
+
+// hasGlob reports whether a path contains glob metacharacters
+func hasGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandGlob returns the files matching a glob pattern in sorted order, like
+// the shell: a trailing zsh glob qualifier is ignored, and hidden files only
+// match a pattern that starts with a dot
+func expandGlob(pattern string) []string {
+	pattern = globQualifierPattern.ReplaceAllString(pattern, "")
+
+	// Glob only fails on malformed patterns
+	matches, _ := filepath.Glob(pattern)
+
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if strings.HasPrefix(filepath.Base(match), ".") && !strings.HasPrefix(filepath.Base(pattern), ".") {
+			continue
+		}
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			continue
+		}
+		files = append(files, match)
+	}
+	return files
+}
@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+func TestLoopTracker(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  parser.Dialect
+		lines    []string
+		wantVars []string
+		wantPats []string
+	}{
+		{
+			name:     "one-liner",
+			lines:    []string{`for f in ~/.bash_aliases.d/*.sh; do source "$f"; done`},
+			wantVars: []string{"f"},
+			wantPats: []string{"~/.bash_aliases.d/*.sh"},
+		},
+		{
+			name:     "multi-line with guard",
+			lines:    []string{"for file in $ZDOTDIR/conf.d/*(N) ~/.zsh/extra.zsh", "do", `  [ -r "$file" ] && . "$file"`, "done"},
+			wantVars: []string{"file"},
+			wantPats: []string{"$ZDOTDIR/conf.d/*(N)", "~/.zsh/extra.zsh"},
+		},
+		{
+			name:     "zsh short form",
+			lines:    []string{"for f ($ZDOTDIR/conf.d/*.zsh(N)) source $f"},
+			wantVars: []string{"f"},
+			wantPats: []string{"$ZDOTDIR/conf.d/*.zsh(N)"},
+		},
+		{
+			name:     "fish loop",
+			dialect:  parser.DialectFish,
+			lines:    []string{"for f in ~/.config/fish/aliases/*.fish", "  source $f", "end"},
+			wantVars: []string{"f"},
+			wantPats: []string{"~/.config/fish/aliases/*.fish"},
+		},
+		{
+			name:  "variable sourced after the loop ended",
+			lines: []string{"for f in a b; do echo $f; done", "source $f"},
+		},
+		{
+			name:     "inner while loop",
+			lines:    []string{"for f in *.sh; do", "  while false; do :; done", "  source ${f}", "done"},
+			wantVars: []string{"f"},
+			wantPats: []string{"*.sh"},
+		},
+		{
+			name:     "fish if nested in a loop",
+			dialect:  parser.DialectFish,
+			lines:    []string{"for f in conf/*.fish; if test -r $f; source $f; end; end"},
+			wantVars: []string{"f"},
+			wantPats: []string{"conf/*.fish"},
+		},
+		{
+			name:     "fish blocks nested in a loop",
+			dialect:  parser.DialectFish,
+			lines:    []string{"for f in conf/*.fish", "  if test -r $f", "    source $f", "  end", "  begin; echo $f; end", "  source $f", "end", "source $f"},
+			wantVars: []string{"f", "f"},
+			wantPats: []string{"conf/*.fish", "conf/*.fish"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewLoopTracker(tt.dialect)
+
+			var vars, pats []string
+			for _, line := range tt.lines {
+				for _, inc := range tracker.ParseLine(line) {
+					vars = append(vars, inc.Variable)
+					pats = append(pats, inc.Patterns...)
+				}
+			}
+
+			if strings.Join(vars, ",") != strings.Join(tt.wantVars, ",") {
+				t.Errorf("ParseLine() variables = %v, want %v", vars, tt.wantVars)
+			}
+			if strings.Join(pats, ",") != strings.Join(tt.wantPats, ",") {
+				t.Errorf("ParseLine() patterns = %v, want %v", pats, tt.wantPats)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+var (
+	// Matches a GitHub repository shorthand: user/repo
+	repoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+	// Matches a git URL, capturing host and repository path: https://github.com/user/repo.git
+	gitURLPattern = regexp.MustCompile(`^(?:[a-z+]+://(?:[^@/]+@)?|[^@/]+@)([^/:]+)[/:](.+?)(?:\.git)?/?$`)
+)
+
+// ohMyZshRepo is the repository managers load oh-my-zsh plugins from
+const ohMyZshRepo = "robbyrussell/oh-my-zsh"
+
+// Bundle is a plugin declared to a zsh plugin manager
+type Bundle struct {
+	Manager string   // zinit, antidote, antigen, zplug or sheldon
+	Name    string   // Bundle as declared, e.g. zsh-users/zsh-autosuggestions
+	Dir     string   // Directory the manager clones the bundle into
+	Path    string   // File or subdirectory of Dir to load, empty for Dir itself
+	Use     []string // Globs selecting the files to source, empty for the defaults
+}
+
+// Files returns the files a bundle sources, or nil if it isn't installed
+func (b Bundle) Files() []string {
+	root := b.Dir
+	if b.Path != "" {
+		root = filepath.Join(root, b.Path)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		return []string{root}
+	}
+
+	// Explicit globs all apply
+	if len(b.Use) > 0 {
+		files := make([]string, 0)
+		for _, glob := range b.Use {
+			files = append(files, expandGlob(filepath.Join(root, glob))...)
+		}
+		return files
+	}
+
+	// Otherwise the first convention a plugin follows wins
+	name := strings.TrimSuffix(filepath.Base(root), ".zsh")
+	for _, glob := range []string{name + ".plugin.zsh", "*.plugin.zsh", "init.zsh", "*.zsh", "*.sh", "*.zsh-theme"} {
+		if files := expandGlob(filepath.Join(root, glob)); len(files) > 0 {
+			return files
+		}
+	}
+	return nil
+}
+
+// parseBundles returns the bundles the plugin manager commands of a logical line load
+func (s *Scanner) parseBundles(line string) []Bundle {
+	var bundles []Bundle
+
+	// eval "$(sheldon source)" or source <(sheldon source)
+	for _, include := range ParseDynamicIncludes(line, parser.DialectPOSIX, model.SourceLocation{}) {
+		if isSheldonSource(include) {
+			bundles = append(bundles, s.sheldonBundles()...)
+		}
+	}
+
+	words, _ := parser.Tokenize(strings.TrimSpace(line))
+	for _, cmd := range parser.SplitCommands(words) {
+		args := make([]string, 0, len(cmd)-1)
+		for _, w := range cmd[1:] {
+			args = append(args, w.Value)
+		}
+
+		switch cmd[0].Value {
+		case "zinit", "zi":
+			bundles = append(bundles, s.zinitBundles(args)...)
+		case "antidote":
+			bundles = append(bundles, s.antidoteBundles(args)...)
+		case "antigen":
+			bundles = append(bundles, s.antigenBundles(args)...)
+		case "zplug":
+			bundles = append(bundles, s.zplugBundles(args)...)
+		}
+	}
+
+	return bundles
+}
+
+// scanBundle scans the files of a bundle as includes of the declaring file,
+// declared under conditions
+func (s *Scanner) scanBundle(bundle Bundle, conditions []model.Condition, sourceFile *model.SourceFile, result *model.ScanResult, visited map[string]bool, depth int) {
+	files := bundle.Files()
+	if len(files) == 0 {
+		sourceFile.Includes = append(sourceFile.Includes, bundle.Dir)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s plugin %s not installed in %s", bundle.Manager, bundle.Name, bundle.Dir))
+		return
+	}
+
+	for _, file := range files {
+		s.scanPluginFile(file, bundle.Manager, bundle.Name, conditions, sourceFile, result, visited, depth)
+	}
+}
+
+// scanPluginFile scans a file a plugin manager loads under conditions,
+// tagging its definitions with the plugin
+func (s *Scanner) scanPluginFile(path string, manager string, plugin string, conditions []model.Condition, sourceFile *model.SourceFile, result *model.ScanResult, visited map[string]bool, depth int) {
+	defer func(manager, plugin string) { s.manager, s.plugin = manager, plugin }(s.manager, s.plugin)
+	s.manager, s.plugin = manager, plugin
+
+	sourceFile.Includes = append(sourceFile.Includes, path)
+	s.scanInclude(path, conditions, result, visited, depth)
+}
+
+// managerDir returns the directory held by variable, or else the first of
+// candidates that exists, or else the first candidate
+func (s *Scanner) managerDir(variable string, candidates ...string) string {
+	if dir, ok := s.pathResolver.ResolvePath("$" + variable); ok {
+		return dir
+	}
+
+	dirs := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if dir, ok := s.pathResolver.ResolvePath(candidate); ok {
+			if FileExists(dir) {
+				return dir
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return candidates[0]
+	}
+	return dirs[0]
+}
+
+// localBundlePath resolves a bundle declared as a local path, such as
+// ~/.zsh/plugins/foo or $ZSH_CUSTOM/plugins/foo
+func (s *Scanner) localBundlePath(name string) (string, bool) {
+	if !strings.HasPrefix(name, "/") && !strings.HasPrefix(name, "~") && !strings.HasPrefix(name, "$") {
+		return "", false
+	}
+	return s.pathResolver.ResolvePath(name)
+}
+
+// zinitBundles returns the bundles of a zinit command:
+//
+//	zinit light user/repo
+//	zinit load user/repo
+//	zinit snippet OMZP::git
+//	zinit wait lucid for user/repo OMZL::git.zsh
+//
+// Plugins are cloned into $ZINIT_HOME/plugins/user---repo and snippets are
+// downloaded into $ZINIT_HOME/snippets.
+func (s *Scanner) zinitBundles(args []string) []Bundle {
+	if len(args) == 0 {
+		return nil
+	}
+
+	home := s.managerDir("ZINIT_HOME", "${XDG_DATA_HOME:-$HOME/.local/share}/zinit", "~/.zinit")
+
+	var names []string
+	switch args[0] {
+	case "light", "load", "snippet":
+		for _, arg := range args[1:] {
+			if !strings.HasPrefix(arg, "-") {
+				names = append(names, arg)
+			}
+		}
+	default:
+		// Ice modifiers followed by a list of plugins: zinit wait lucid for ...
+		for i, arg := range args {
+			if arg != "for" {
+				continue
+			}
+			for _, name := range args[i+1:] {
+				if repoPattern.MatchString(name) || strings.Contains(name, "::") {
+					names = append(names, name)
+				}
+			}
+			break
+		}
+	}
+
+	bundles := make([]Bundle, 0, len(names))
+	for _, name := range names {
+		bundle := Bundle{Manager: "zinit", Name: name}
+		switch {
+		case args[0] == "snippet" || strings.Contains(name, "::") || strings.Contains(name, "://"):
+			bundle.Dir, bundle.Path = zinitSnippet(home, name)
+		case repoPattern.MatchString(name):
+			bundle.Dir = filepath.Join(home, "plugins", strings.Replace(name, "/", "---", 1))
+		default:
+			dir, ok := s.localBundlePath(name)
+			if !ok {
+				continue
+			}
+			bundle.Dir = dir
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles
+}
+
+// zinitSnippet returns the directory zinit downloads a snippet into, and the
+// snippet file within it when known. Snippets keep their shorthand as the
+// directory name (OMZP::git); URLs have their slashes replaced by --.
+func zinitSnippet(home string, name string) (string, string) {
+	id := strings.TrimSuffix(name, "/")
+	if i := strings.Index(id, "://"); i >= 0 {
+		id = id[i+3:]
+	}
+	dir := filepath.Join(home, "snippets", strings.ReplaceAll(id, "/", "--"))
+
+	// A single file snippet is saved under its own name
+	for _, file := range []string{filepath.Base(name), filepath.Base(id), name} {
+		if info, err := os.Stat(filepath.Join(dir, file)); err == nil && !info.IsDir() {
+			return dir, file
+		}
+	}
+	return dir, ""
+}
+
+// antidoteBundles returns the bundles of `antidote load [file]`, which reads
+// ${ZDOTDIR:-~}/.zsh_plugins.txt by default. Each line of the file declares a
+// bundle with optional annotations:
+//
+//	zsh-users/zsh-autosuggestions
+//	ohmyzsh/ohmyzsh path:plugins/git
+//	romkatv/zsh-defer kind:defer
+//
+// Repositories are cloned into $ANTIDOTE_HOME, by default the antidote
+// directory of the user cache.
+func (s *Scanner) antidoteBundles(args []string) []Bundle {
+	if len(args) == 0 || args[0] != "load" {
+		return nil
+	}
+
+	bundleFile := "${ZDOTDIR:-$HOME}/.zsh_plugins.txt"
+	if len(args) > 1 {
+		bundleFile = args[1]
+	}
+	path, ok := s.pathResolver.ResolvePath(bundleFile)
+	if !ok {
+		return nil
+	}
+	lines, err := s.fileReader.ReadLines(path)
+	if err != nil {
+		return nil
+	}
+
+	home := s.managerDir("ANTIDOTE_HOME", "${XDG_CACHE_HOME:-$HOME/.cache}/antidote", "~/Library/Caches/antidote")
+
+	bundles := make([]Bundle, 0)
+	for _, line := range lines {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		bundle := Bundle{Manager: "antidote", Name: fields[0]}
+		sourced := true
+		for _, annotation := range fields[1:] {
+			key, value, _ := strings.Cut(annotation, ":")
+			switch key {
+			case "path":
+				bundle.Path = value
+			case "kind":
+				// Other kinds only clone the bundle or add it to $fpath or $PATH
+				sourced = value == "zsh" || value == "defer"
+			}
+		}
+		if !sourced {
+			continue
+		}
+
+		if dir, ok := s.localBundlePath(bundle.Name); ok {
+			bundle.Dir = dir
+		} else {
+			bundle.Dir = antidoteRepoDir(home, bundle.Name)
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles
+}
+
+// antidoteRepoDir returns the directory antidote clones a repository into.
+// Recent versions name it after the repository, older ones after its escaped URL.
+func antidoteRepoDir(home string, repo string) string {
+	url := repo
+	if repoPattern.MatchString(repo) {
+		url = "https://github.com/" + repo
+	}
+	dirs := []string{
+		filepath.Join(home, repo),
+		filepath.Join(home, repoPath(url)),
+		filepath.Join(home, strings.NewReplacer(":", "-COLON-", "/", "-SLASH-").Replace(url)),
+	}
+	if dir, ok := firstExisting(dirs...); ok {
+		return dir
+	}
+	return dirs[0]
+}
+
+// repoPath returns host/path for a git URL, e.g. github.com/user/repo
+func repoPath(url string) string {
+	if matches := gitURLPattern.FindStringSubmatch(url); matches != nil {
+		return matches[1] + "/" + matches[2]
+	}
+	return url
+}
+
+// antigenBundles returns the bundles of an antigen command:
+//
+//	antigen use oh-my-zsh
+//	antigen bundle git
+//	antigen bundle user/repo [path]
+//	antigen theme robbyrussell
+//
+// Bare names refer to oh-my-zsh plugins. Repositories are cloned into
+// $ADOTDIR/bundles/user/repo, with $ADOTDIR defaulting to ~/.antigen.
+func (s *Scanner) antigenBundles(args []string) []Bundle {
+	if len(args) < 2 {
+		return nil
+	}
+
+	bundlesDir := filepath.Join(s.managerDir("ADOTDIR", "~/.antigen"), "bundles")
+	omz := filepath.Join(bundlesDir, ohMyZshRepo)
+
+	var positional []string
+	loc := ""
+	for _, arg := range args[1:] {
+		if value, ok := strings.CutPrefix(arg, "--loc="); ok {
+			loc = value
+		} else if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) == 0 {
+		return nil
+	}
+	name := positional[0]
+	bundle := Bundle{Manager: "antigen", Name: name, Path: loc}
+
+	switch args[0] {
+	case "use":
+		if name != "oh-my-zsh" {
+			return nil
+		}
+		bundle.Dir, bundle.Path, bundle.Use = omz, "lib", []string{"*.zsh"}
+	case "theme":
+		bundle.Dir, bundle.Path = omz, filepath.Join("themes", name+".zsh-theme")
+		if repoPattern.MatchString(name) {
+			bundle.Dir, bundle.Path = filepath.Join(bundlesDir, name), ""
+		}
+		bundle.Name = "theme:" + name
+	case "bundle":
+		if len(positional) > 1 {
+			bundle.Path = positional[1]
+		}
+		switch {
+		case repoPattern.MatchString(name):
+			bundle.Dir = filepath.Join(bundlesDir, name)
+		case strings.Contains(name, "://") || strings.Contains(name, "@"):
+			bundle.Dir = filepath.Join(bundlesDir, strings.TrimPrefix(repoPath(name), "github.com/"))
+		default:
+			if dir, ok := s.localBundlePath(name); ok {
+				bundle.Dir = dir
+			} else {
+				bundle.Dir, bundle.Path = omz, filepath.Join("plugins", name)
+			}
+		}
+	default:
+		return nil
+	}
+
+	return []Bundle{bundle}
+}
+
+// zplugBundles returns the bundle of a zplug declaration:
+//
+//	zplug "zsh-users/zsh-syntax-highlighting", defer:2
+//	zplug "plugins/git", from:oh-my-zsh
+//	zplug "~/.zsh", from:local, use:"*.zsh"
+//
+// Repositories are cloned into $ZPLUG_REPOS, by default ~/.zplug/repos/user/repo.
+func (s *Scanner) zplugBundles(args []string) []Bundle {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return nil
+	}
+
+	name := strings.TrimSuffix(args[0], ",")
+	switch name {
+	case "check", "install", "load", "update", "list", "clean", "clear", "info", "status":
+		return nil
+	}
+
+	tags := make(map[string]string)
+	for _, arg := range args[1:] {
+		if key, value, ok := strings.Cut(strings.TrimSuffix(arg, ","), ":"); ok {
+			tags[key] = value
+		}
+	}
+	if as := tags["as"]; as != "" && as != "plugin" && as != "theme" {
+		return nil // Commands are added to $PATH, not sourced
+	}
+
+	repos := s.managerDir("ZPLUG_REPOS", "${ZPLUG_HOME:-$HOME/.zplug}/repos")
+	bundle := Bundle{Manager: "zplug", Name: name}
+	if use := tags["use"]; use != "" {
+		bundle.Use = []string{use}
+	}
+
+	switch tags["from"] {
+	case "", "github":
+		bundle.Dir = filepath.Join(repos, name)
+	case "oh-my-zsh":
+		bundle.Dir, bundle.Path = filepath.Join(repos, ohMyZshRepo), name
+	case "prezto":
+		bundle.Dir, bundle.Path = filepath.Join(repos, "sorin-ionescu/prezto"), name
+	case "local":
+		dir, ok := s.pathResolver.ResolvePath(name)
+		if !ok {
+			return nil
+		}
+		bundle.Dir = dir
+	default:
+		return nil // Release binaries and gists
+	}
+
+	return []Bundle{bundle}
+}
@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanPluginManagers(t *testing.T) {
+	dir := t.TempDir()
+
+	// Plugins as each manager clones them
+	writeFile(t, dir, "zinit/plugins/zsh-users---zsh-autosuggestions/zsh-autosuggestions.plugin.zsh", "alias zas=autosuggest\n")
+	writeFile(t, dir, "zinit/plugins/agkozak---zsh-z/zsh-z.plugin.zsh", "alias zz=zshz\n")
+	writeFile(t, dir, "zinit/snippets/OMZP::git/git.plugin.zsh", "alias gst='git status'\n")
+	writeFile(t, dir, "antidote/github.com/ohmyzsh/ohmyzsh/plugins/docker/docker.plugin.zsh", "alias dps='docker ps'\n")
+	writeFile(t, dir, "antidote/github.com/romkatv/zsh-bench/zsh-bench.zsh", "alias bench=zsh-bench\n")
+	writeFile(t, dir, "antigen/bundles/robbyrussell/oh-my-zsh/lib/directories.zsh", "alias md='mkdir -p'\n")
+	writeFile(t, dir, "antigen/bundles/robbyrussell/oh-my-zsh/plugins/kubectl/kubectl.plugin.zsh", "alias k=kubectl\n")
+	writeFile(t, dir, "antigen/bundles/robbyrussell/oh-my-zsh/themes/ys.zsh-theme", "alias ys-reset='PROMPT=%#'\n")
+	writeFile(t, dir, "zplug/repos/robbyrussell/oh-my-zsh/plugins/tmux/tmux.plugin.zsh", "alias ta='tmux attach'\n")
+	writeFile(t, dir, "zplug/repos/b4b4r07/enhancd/init.sh", "alias ecd=__enhancd::cd\n")
+	writeFile(t, dir, "sheldon/repos/github.com/zdharma/fast-syntax-highlighting/fast-syntax-highlighting.plugin.zsh", "alias fsh=fast-theme\n")
+	writeFile(t, dir, "sheldon/repos/gitlab.com/me/dots/aliases.zsh", "alias dots='cd ~/dots'\n")
+
+	writeFile(t, dir, ".zsh_plugins.txt", `# antidote bundles
+ohmyzsh/ohmyzsh path:plugins/docker
+romkatv/zsh-bench
+romkatv/zsh-defer kind:clone
+`)
+	writeFile(t, dir, "plugins.toml", `shell = "zsh"
+
+[plugins.fast-syntax-highlighting]
+github = "zdharma/fast-syntax-highlighting"
+
+[plugins.dots]
+git = "https://gitlab.com/me/dots.git"
+use = [
+  "aliases.zsh",
+]
+
+[plugins.compinit]
+inline = "autoload -Uz compinit && compinit"
+`)
+	rc := writeFile(t, dir, ".zshrc", `zinit light zsh-users/zsh-autosuggestions
+zinit ice wait lucid
+zinit snippet OMZP::git
+zinit wait lucid for agkozak/zsh-z
+antidote load `+dir+`/.zsh_plugins.txt
+antigen use oh-my-zsh
+antigen bundle kubectl
+antigen bundle zsh-users/zsh-completions
+antigen theme ys
+zplug "plugins/tmux", from:oh-my-zsh
+zplug "b4b4r07/enhancd", use:init.sh
+zplug "junegunn/fzf", as:command
+eval "$(sheldon source)"
+`)
+
+	s := NewScanner()
+	s.SetEnvironment(map[string]string{
+		"ZINIT_HOME":          filepath.Join(dir, "zinit"),
+		"ANTIDOTE_HOME":       filepath.Join(dir, "antidote"),
+		"ADOTDIR":             filepath.Join(dir, "antigen"),
+		"ZPLUG_REPOS":         filepath.Join(dir, "zplug/repos"),
+		"SHELDON_CONFIG_FILE": filepath.Join(dir, "plugins.toml"),
+		"SHELDON_DATA_DIR":    filepath.Join(dir, "sheldon"),
+	})
+	result, err := s.ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		alias       string
+		wantManager string
+		wantPlugin  string
+	}{
+		{alias: "zas", wantManager: "zinit", wantPlugin: "zsh-users/zsh-autosuggestions"},
+		{alias: "gst", wantManager: "zinit", wantPlugin: "OMZP::git"},
+		{alias: "zz", wantManager: "zinit", wantPlugin: "agkozak/zsh-z"},
+		{alias: "dps", wantManager: "antidote", wantPlugin: "ohmyzsh/ohmyzsh"},
+		{alias: "bench", wantManager: "antidote", wantPlugin: "romkatv/zsh-bench"},
+		{alias: "md", wantManager: "antigen", wantPlugin: "oh-my-zsh"},
+		{alias: "k", wantManager: "antigen", wantPlugin: "kubectl"},
+		{alias: "ys-reset", wantManager: "antigen", wantPlugin: "theme:ys"},
+		{alias: "ta", wantManager: "zplug", wantPlugin: "plugins/tmux"},
+		{alias: "ecd", wantManager: "zplug", wantPlugin: "b4b4r07/enhancd"},
+		{alias: "fsh", wantManager: "sheldon", wantPlugin: "fast-syntax-highlighting"},
+		{alias: "dots", wantManager: "sheldon", wantPlugin: "dots"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			entry, ok := result.Aliases[tt.alias]
+			if !ok {
+				t.Fatalf("alias %q not found", tt.alias)
+			}
+			if entry.Plugin != tt.wantPlugin {
+				t.Errorf("alias %q plugin = %q, want %q", tt.alias, entry.Plugin, tt.wantPlugin)
+			}
+
+			file := result.Files[entry.ActiveLocation.FilePath]
+			if file == nil || file.Manager != tt.wantManager || file.Plugin != tt.wantPlugin {
+				t.Errorf("file %s = %+v, want manager %q and plugin %q", entry.ActiveLocation.FilePath, file, tt.wantManager, tt.wantPlugin)
+			}
+		})
+	}
+
+	// zsh-completions isn't cloned; zsh-defer and fzf are never sourced
+	warnings := strings.Join(result.Warnings, "\n")
+	if !strings.Contains(warnings, "antigen plugin zsh-users/zsh-completions not installed") {
+		t.Errorf("Warnings = %v, want the missing antigen bundle reported", result.Warnings)
+	}
+	if strings.Contains(warnings, "zsh-defer") || strings.Contains(warnings, "fzf") {
+		t.Errorf("Warnings = %v, want bundles that aren't sourced ignored", result.Warnings)
+	}
+}
+
+func TestParseSheldonPlugins(t *testing.T) {
+	plugins := parseSheldonPlugins([]string{
+		`[plugins."zsh-defer"]`,
+		`github = 'romkatv/zsh-defer'`,
+		`apply = ["source"]`,
+		`[templates]`,
+		`defer = "{{ hooks?.pre | nl }}"`,
+		`[plugins.base16]`,
+		`github = "chriskempson/base16-shell"`,
+		`use = ["scripts/{{ name }}.sh", # comment`,
+		`  "profile_helper.sh"]`,
+	})
+
+	if len(plugins) != 2 {
+		t.Fatalf("parseSheldonPlugins() returned %d plugins, want 2", len(plugins))
+	}
+	if plugins[0].name != "zsh-defer" || strings.Join(plugins[0].values["github"], ",") != "romkatv/zsh-defer" {
+		t.Errorf("plugin 0 = %+v, want zsh-defer from romkatv/zsh-defer", plugins[0])
+	}
+	if got := strings.Join(plugins[1].values["use"], ","); got != "scripts/{{ name }}.sh,profile_helper.sh" {
+		t.Errorf("plugin 1 use = %q, want both globs", got)
+	}
+}
+
+func TestSheldonSourceMentioned(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "sheldon/repos/gitlab.com/me/dots/aliases.zsh", "alias dots='cd ~/dots'\n")
+	writeFile(t, dir, "plugins.toml", `[plugins.dots]
+git = "https://gitlab.com/me/dots.git"
+`)
+	rc := writeFile(t, dir, ".zshrc", `# eval "$(sheldon source)"
+echo 'run eval "$(sheldon source)" to load plugins'
+eval "$(sheldon lock)"
+`)
+
+	s := NewScanner()
+	s.SetEnvironment(map[string]string{
+		"SHELDON_CONFIG_FILE": filepath.Join(dir, "plugins.toml"),
+		"SHELDON_DATA_DIR":    filepath.Join(dir, "sheldon"),
+	})
+	result, err := s.ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	if _, ok := result.Aliases["dots"]; ok {
+		t.Errorf("alias dots found, want sheldon plugins loaded only by a sheldon source command")
+	}
+}
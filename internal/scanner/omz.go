@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+// Matches the oh-my-zsh plugin list: plugins=(...) or plugins+=(...). The
+// tokenizer splits an empty list plugins=() into plugins= and two operators.
+var pluginsArrayPattern = regexp.MustCompile(`^plugins(\+?)=(?:\(((?s).*)\))?$`)
+
+// ohMyZshScript is the file oh-my-zsh users source from their .zshrc
+const ohMyZshScript = "oh-my-zsh.sh"
+
+// isOhMyZsh reports whether a file is the oh-my-zsh loader
+func isOhMyZsh(path string) bool {
+	return filepath.Base(path) == ohMyZshScript
+}
+
+// ParsePluginsArray returns the plugins a logical line assigns to the
+// oh-my-zsh plugins array, and whether the line appends to it with +=
+func ParsePluginsArray(line string) ([]string, bool, bool) {
+	words, _ := parser.Tokenize(strings.TrimSpace(line))
+	for _, cmd := range parser.SplitCommands(words) {
+		if cmd[0].Kind != parser.WordLiteral {
+			continue
+		}
+
+		matches := pluginsArrayPattern.FindStringSubmatch(cmd[0].Raw)
+		if matches == nil {
+			continue
+		}
+
+		plugins := make([]string, 0)
+		for _, item := range strings.Split(matches[2], "\n") {
+			// Multi-line arrays often comment out plugins
+			item, _, _ = strings.Cut(item, "#")
+			plugins = append(plugins, strings.Fields(item)...)
+		}
+		return plugins, matches[1] == "+", true
+	}
+
+	return nil, false, false
+}
+
+// trackPlugins records the oh-my-zsh plugins array set on a logical line
+func (s *Scanner) trackPlugins(line string) {
+	plugins, appended, ok := ParsePluginsArray(line)
+	if !ok {
+		return
+	}
+	if !appended {
+		s.plugins = nil
+	}
+	s.plugins = append(s.plugins, plugins...)
+}
+
+// scanOhMyZsh follows the files oh-my-zsh.sh loads instead of parsing the
+// script itself, which picks them through functions the scanner can't follow:
+//
+//	$ZSH/lib/*.zsh                                 overridden by $ZSH_CUSTOM/lib/<name>
+//	$ZSH_CUSTOM/plugins/<name>/<name>.plugin.zsh   for each entry of $plugins,
+//	$ZSH/plugins/<name>/<name>.plugin.zsh          custom plugins first
+//	$ZSH_CUSTOM/*.zsh
+//	$ZSH_CUSTOM/themes/$ZSH_THEME.zsh-theme        first one found
+//	$ZSH_CUSTOM/$ZSH_THEME.zsh-theme
+//	$ZSH/themes/$ZSH_THEME.zsh-theme
+//
+// Definitions found in a plugin or theme are tagged with its name.
+func (s *Scanner) scanOhMyZsh(scriptPath string, sourceFile *model.SourceFile, result *model.ScanResult, visited map[string]bool, depth int) {
+	// oh-my-zsh.sh defaults $ZSH to its own directory and $ZSH_CUSTOM to $ZSH/custom
+	zsh, ok := s.pathResolver.ResolvePath("$ZSH")
+	if !ok {
+		zsh = filepath.Dir(scriptPath)
+		s.pathResolver.SetVariable("ZSH", zsh)
+	}
+	custom, ok := s.pathResolver.ResolvePath("$ZSH_CUSTOM")
+	if !ok {
+		custom = filepath.Join(zsh, "custom")
+		s.pathResolver.SetVariable("ZSH_CUSTOM", custom)
+	}
+
+	include := func(path string, plugin string) {
+		manager := ""
+		if plugin != "" {
+			manager = "oh-my-zsh"
+		}
+		s.scanPluginFile(path, manager, plugin, nil, sourceFile, result, visited, depth)
+	}
+
+	for _, lib := range expandGlob(filepath.Join(zsh, "lib", "*.zsh")) {
+		if override := filepath.Join(custom, "lib", filepath.Base(lib)); FileExists(override) {
+			lib = override
+		}
+		include(lib, "")
+	}
+
+	for _, name := range s.plugins {
+		path, ok := firstExisting(
+			filepath.Join(custom, "plugins", name, name+".plugin.zsh"),
+			filepath.Join(zsh, "plugins", name, name+".plugin.zsh"),
+		)
+		if !ok {
+			if !FileExists(filepath.Join(custom, "plugins", name)) && !FileExists(filepath.Join(zsh, "plugins", name)) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("oh-my-zsh plugin %s not found in %s", name, zsh))
+			}
+			continue // Completion-only plugins have no plugin file
+		}
+		include(path, name)
+	}
+
+	for _, file := range expandGlob(filepath.Join(custom, "*.zsh")) {
+		include(file, "")
+	}
+
+	if theme, ok := s.pathResolver.LookupVariable("ZSH_THEME"); ok && theme != "" && theme != "random" {
+		path, ok := firstExisting(
+			filepath.Join(custom, "themes", theme+".zsh-theme"),
+			filepath.Join(custom, theme+".zsh-theme"),
+			filepath.Join(zsh, "themes", theme+".zsh-theme"),
+		)
+		if ok {
+			include(path, "theme:"+theme)
+		}
+	}
+}
+
+// firstExisting returns the first of paths that exists
+func firstExisting(paths ...string) (string, bool) {
+	for _, path := range paths {
+		if FileExists(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
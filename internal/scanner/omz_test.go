@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePluginsArray(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		wantPlugins  []string
+		wantAppended bool
+		wantOK       bool
+	}{
+		{
+			name:        "single line",
+			line:        "plugins=(git docker kubectl)",
+			wantPlugins: []string{"git", "docker", "kubectl"},
+			wantOK:      true,
+		},
+		{
+			name:        "multi-line with comments",
+			line:        "plugins=(\n  git\n  # docker\n  z # it's a jumper\n)",
+			wantPlugins: []string{"git", "z"},
+			wantOK:      true,
+		},
+		{
+			name:         "append",
+			line:         "plugins+=(fzf)",
+			wantPlugins:  []string{"fzf"},
+			wantAppended: true,
+			wantOK:       true,
+		},
+		{
+			name:   "empty",
+			line:   "plugins=()",
+			wantOK: true,
+		},
+		{
+			name:        "after a guard",
+			line:        "[[ -n $SSH ]] && plugins=(ssh-agent)",
+			wantPlugins: []string{"ssh-agent"},
+			wantOK:      true,
+		},
+		{
+			name: "other array",
+			line: "fpath=(~/.zfunc $fpath)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugins, appended, ok := ParsePluginsArray(tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("ParsePluginsArray() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if strings.Join(plugins, ",") != strings.Join(tt.wantPlugins, ",") {
+				t.Errorf("ParsePluginsArray() plugins = %v, want %v", plugins, tt.wantPlugins)
+			}
+			if appended != tt.wantAppended {
+				t.Errorf("ParsePluginsArray() appended = %v, want %v", appended, tt.wantAppended)
+			}
+		})
+	}
+}
+
+func TestScanOhMyZsh(t *testing.T) {
+	dir := t.TempDir()
+	omz := dir + "/.oh-my-zsh"
+	writeFile(t, omz, "oh-my-zsh.sh", "for plugin ($plugins); do _omz_source \"plugins/$plugin/$plugin.plugin.zsh\"; done\n")
+	writeFile(t, omz, "lib/directories.zsh", "alias md='mkdir -p'\n")
+	writeFile(t, omz, "plugins/git/git.plugin.zsh", "alias gst='git status'\nalias gco='git checkout'\n")
+	writeFile(t, omz, "plugins/docker/docker.plugin.zsh", "alias dps='docker ps'\n")
+	writeFile(t, omz, "plugins/docker/_docker", "#compdef docker\n")
+	writeFile(t, omz, "plugins/kubectl/kubectl.plugin.zsh", "alias k=kubectl\n")
+	writeFile(t, omz, "themes/robbyrussell.zsh-theme", "alias prompt-reset='PROMPT=%# '\n")
+	writeFile(t, omz, "custom/plugins/docker/docker.plugin.zsh", "alias dps='docker ps -a'\n")
+	writeFile(t, omz, "custom/aliases.zsh", "alias gst='git status -sb'\n")
+	rc := writeFile(t, dir, ".zshrc", `export ZSH="`+omz+`"
+ZSH_THEME="robbyrussell"
+plugins=(
+  git
+  docker
+)
+plugins+=(kubectl missing)
+source $ZSH/oh-my-zsh.sh
+alias k='kubectl --context dev'
+`)
+
+	result, err := NewScanner().ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		wantValue  string
+		wantPlugin string
+	}{
+		{name: "md", wantValue: "mkdir -p"},
+		{name: "gco", wantValue: "git checkout", wantPlugin: "git"},
+		{name: "dps", wantValue: "docker ps -a", wantPlugin: "docker"}, // $ZSH_CUSTOM wins
+		{name: "gst", wantValue: "git status -sb"},                     // Custom files load after plugins
+		{name: "k", wantValue: "kubectl --context dev"},                // .zshrc overrides after sourcing
+		{name: "prompt-reset", wantValue: "PROMPT=%# ", wantPlugin: "theme:robbyrussell"},
+	}
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.name]
+		if !ok {
+			t.Errorf("alias %q not found", tt.name)
+			continue
+		}
+		if entry.ActiveValue != tt.wantValue || entry.Plugin != tt.wantPlugin {
+			t.Errorf("alias %q = %q from plugin %q, want %q from plugin %q", tt.name, entry.ActiveValue, entry.Plugin, tt.wantValue, tt.wantPlugin)
+		}
+	}
+
+	if defs := result.Aliases["k"].Definitions; len(defs) != 2 || defs[0].Plugin != "kubectl" {
+		t.Errorf("alias k definitions = %+v, want the kubectl plugin's first", defs)
+	}
+
+	found := false
+	for _, warning := range result.Warnings {
+		found = found || strings.Contains(warning, "plugin missing not found")
+	}
+	if !found {
+		t.Errorf("Warnings = %v, want the missing plugin reported", result.Warnings)
+	}
+}
@@ -3,10 +3,12 @@ package scanner
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/oscar.rivas/falias/internal/model"
 	"github.com/oscar.rivas/falias/internal/parser"
+	"github.com/oscar.rivas/falias/internal/profile"
 	"github.com/oscar.rivas/falias/internal/resolve"
 )
 
@@ -17,25 +19,68 @@ const (
 // Scanner orchestrates the scanning of shell configuration files
 type Scanner struct {
 	pathResolver  *resolve.PathResolver
-	aliasParser   *parser.AliasParser
+	aliasParser   *parser.AliasParser // Set for the shell of each scan
+	fishParser    *parser.FishParser
 	includeParser *IncludeParser
 	fileReader    *FileReader
+	session       Session            // Session whose skipped startup files are recorded, if set
+	environment   map[string]string  // Variables the shell starts with, which may move startup files
+	evaluator     *profile.Evaluator // Decides conditions on the target profile, if set
+	plugins       []string           // oh-my-zsh plugins array as the scanned files set it
+	manager       string             // Plugin manager that loads the files being scanned
+	plugin        string             // Plugin or theme whose files are being scanned
+
+	// Conditions the file being scanned was sourced under, which apply to all it
+	// defines, and whether they are false on the target profile
+	conditions []model.Condition
+	excluded   bool
 }
 
 // NewScanner creates a new scanner
 func NewScanner() *Scanner {
 	return &Scanner{
 		pathResolver:  resolve.NewPathResolver(),
-		aliasParser:   parser.NewAliasParser(),
+		fishParser:    parser.NewFishParser(),
 		includeParser: NewIncludeParser(),
 		fileReader:    NewFileReader(),
 	}
 }
 
+// SetSession makes ScanShellFiles record the startup files the session never
+// reads. Only set it when scanning the session's default root files.
+func (s *Scanner) SetSession(session Session) {
+	s.session = session
+}
+
+// SetEnvironment sets the variables every scan starts with, as if exported by
+// the process that starts the shell
+func (s *Scanner) SetEnvironment(vars map[string]string) {
+	s.environment = vars
+	s.pathResolver.SetEnvironment(vars)
+}
+
+// SetEvaluator makes scans evaluate the conditions of definitions, excluding
+// those that are false on the evaluator's target profile from the active aliases
+func (s *Scanner) SetEvaluator(evaluator *profile.Evaluator) {
+	s.evaluator = evaluator
+}
+
 // ScanShellFiles scans shell configuration files starting from the given root paths
 func (s *Scanner) ScanShellFiles(shell string, rootPaths []string) (*model.ScanResult, error) {
 	result := model.NewScanResult(shell, rootPaths)
 
+	// Each scan starts from a clean variable environment
+	s.pathResolver.ResetVariables()
+	s.aliasParser = parser.NewAliasParser(shell)
+	s.plugins = nil
+	s.conditions = nil
+	s.excluded = false
+
+	// Commands are looked up on the PATH the shell inherits, as the files change it
+	if _, ok := s.pathResolver.LookupVariable("PATH"); !ok {
+		s.pathResolver.SetVariable("PATH", os.Getenv("PATH"))
+	}
+
 	// Track visited files to prevent loops
 	visited := make(map[string]bool)
 
@@ -52,9 +97,54 @@ func (s *Scanner) ScanShellFiles(shell string, rootPaths []string) (*model.ScanR
 		}
 	}
 
+	if s.session != "" {
+		s.recordSkippedFiles(shell, result)
+	}
+
+	result.SearchPath = s.searchPath()
+	s.detectShadows(result)
+	s.expandAliases(result)
+	s.detectCycles(result)
+	s.detectDeadAliases(result)
+
 	return result, nil
 }
 
+// recordSkippedFiles adds the existing startup files the session never reads,
+// unless another file sourced them
+func (s *Scanner) recordSkippedFiles(shell string, result *model.ScanResult) {
+	for _, file := range StartupSequence(shell, s.session, s.environment) {
+		if !file.Skipped || !FileExists(file.Path) {
+			continue
+		}
+
+		canonPath, err := resolve.Canonicalize(file.Path)
+		if err != nil {
+			canonPath = file.Path
+		}
+		if _, scanned := result.Files[canonPath]; scanned {
+			continue
+		}
+
+		result.Files[canonPath] = &model.SourceFile{
+			Path:      canonPath,
+			Exists:    true,
+			Readable:  FileReadable(canonPath),
+			Skipped:   true,
+			Aliases:   make([]model.AliasDefinition, 0),
+			Functions: make([]model.FunctionDefinition, 0),
+			Includes:  make([]string, 0),
+			Dynamic:   make([]model.DynamicInclude, 0),
+		}
+	}
+}
+
+// functionLineParser is implemented by the per-file function parsers of each dialect
+type functionLineParser interface {
+	ParseLine(line string, filePath string, lineNum int) []model.FunctionDefinition
+	Pending() (*model.FunctionDefinition, bool)
+}
+
 // scanFile recursively scans a single file
 func (s *Scanner) scanFile(filePath string, result *model.ScanResult, visited map[string]bool, depth int) error {
 	// Check depth limit
@@ -77,11 +167,17 @@ func (s *Scanner) scanFile(filePath string, result *model.ScanResult, visited ma
 
 	// Create source file entry
 	sourceFile := &model.SourceFile{
-		Path:     canonPath,
-		Exists:   FileExists(canonPath),
-		Readable: FileReadable(canonPath),
-		Aliases:  make([]model.AliasDefinition, 0),
-		Includes: make([]string, 0),
+		Path:       canonPath,
+		Exists:     FileExists(canonPath),
+		Readable:   FileReadable(canonPath),
+		Aliases:    make([]model.AliasDefinition, 0),
+		Functions:  make([]model.FunctionDefinition, 0),
+		Includes:   make([]string, 0),
+		Dynamic:    make([]model.DynamicInclude, 0),
+		Manager:    s.manager,
+		Plugin:     s.plugin,
+		Conditions: s.conditions,
+		Excluded:   s.excluded,
 	}
 
 	// Store the file entry
@@ -98,6 +194,12 @@ func (s *Scanner) scanFile(filePath string, result *model.ScanResult, visited ma
 		return nil
 	}
 
+	// oh-my-zsh.sh loads its plugins through functions, follow them directly
+	if isOhMyZsh(canonPath) && result.Shell != "fish" {
+		s.scanOhMyZsh(canonPath, sourceFile, result, visited, depth)
+		return nil
+	}
+
 	// Read file lines
 	lines, err := s.fileReader.ReadLines(canonPath)
 	if err != nil {
@@ -105,15 +207,114 @@ func (s *Scanner) scanFile(filePath string, result *model.ScanResult, visited ma
 		return err
 	}
 
-	// Parse each line
-	for lineNum, line := range lines {
-		lineNumber := lineNum + 1 // Line numbers start at 1
+	// Paths sourced by this file resolve relative to it
+	defer s.pathResolver.SetCurrentFile(s.pathResolver.CurrentFile())
+	s.pathResolver.SetCurrentFile(canonPath)
 
-		// Try to parse as alias
-		if parser.IsAliasLine(line) {
-			if aliasDef, ok := s.aliasParser.ParseLine(line, canonPath, lineNumber); ok {
-				sourceFile.Aliases = append(sourceFile.Aliases, *aliasDef)
-				result.AddAlias(*aliasDef)
+	fish := result.Shell == "fish"
+	dialect := parser.DialectPOSIX
+
+	// Function definitions, loops and conditional blocks span several lines, so
+	// their parsers keep per-file state
+	var functionParser functionLineParser = parser.NewFunctionParser()
+	if fish {
+		dialect = parser.DialectFish
+		functionParser = parser.NewFishFunctionParser()
+	}
+	loops := NewLoopTracker(dialect)
+	conditions := NewConditionTracker(dialect)
+
+	// Parse each logical line, joining continuations and multi-line quotes
+	for _, logical := range JoinLogicalLinesDialect(lines, dialect) {
+		line := logical.Text
+		lineNumber := logical.StartLine
+
+		// Track function definitions
+		_, wasOpen := functionParser.Pending()
+		fns := functionParser.ParseLine(line, canonPath, lineNumber)
+		for _, fn := range fns {
+			// fish saves aliases as functions described as `alias name=value`
+			if aliasDef, ok := parser.FishFunctionAlias(fn); fish && ok {
+				s.addAlias(aliasDef, sourceFile, result)
+				continue
+			}
+			sourceFile.Functions = append(sourceFile.Functions, fn)
+			result.AddFunction(fn)
+		}
+
+		// Assignments in a function body only take effect when it runs, so
+		// they are scoped to the function
+		function := ""
+		pending, open := functionParser.Pending()
+		if open {
+			function = pending.Name
+		} else if len(fns) > 0 {
+			function = fns[len(fns)-1].Name
+		}
+		if function != "" && !wasOpen {
+			s.pathResolver.PushScope()
+		}
+
+		// Track variable assignments for path resolution
+		location := model.SourceLocation{FilePath: canonPath, LineNum: lineNumber, EndLine: logical.EndLine, RawLine: line}
+		s.trackVariables(line, function, location, result)
+
+		// Each command runs under the conditions of the blocks enclosing it, and
+		// in a function body only when the function runs
+		outer := conditions.Conditions()
+		commands := conditions.ParseLine(line)
+		if function != "" {
+			runs := []model.Condition{{Text: function, Function: true}}
+			outer = joinConditions(outer, runs)
+			for i := range commands {
+				commands[i].Conditions = joinConditions(commands[i].Conditions, runs)
+			}
+		}
+
+		if !fish {
+			s.trackPlugins(line)
+		}
+		if function == "" {
+			s.trackSearchPath(line, commands, fish, location, result)
+		}
+		for _, cmd := range commands {
+			if fish {
+				s.parseFishLine(cmd.Text(), location, cmd.Conditions, sourceFile, result)
+			} else {
+				s.parseAliasLine(cmd.Text(), location, cmd.Conditions, sourceFile, result)
+			}
+		}
+
+		// Expand files sourced through a loop variable to the files the loop iterates over
+		loopIncludes := loops.ParseLine(line)
+		for _, inc := range loopIncludes {
+			guard := sourceConditions(commands, func(arg string) bool { return refersToVariable(arg, inc.Variable) }, outer)
+			for _, pattern := range inc.Patterns {
+				resolvedPattern, ok := s.pathResolver.ResolvePath(pattern)
+				if !ok {
+					sourceFile.Includes = append(sourceFile.Includes, pattern)
+					result.UnresolvedPaths = append(result.UnresolvedPaths, pattern)
+					continue
+				}
+
+				matches := []string{resolvedPattern}
+				if hasGlob(resolvedPattern) {
+					matches = expandGlob(resolvedPattern)
+				}
+				for _, match := range matches {
+					sourceFile.Includes = append(sourceFile.Includes, match)
+					s.scanInclude(match, guard, result, visited, depth)
+				}
+			}
+		}
+
+		// Code generated by commands can't be scanned, record where it comes from
+		sourceFile.Dynamic = append(sourceFile.Dynamic, ParseDynamicIncludes(line, dialect, location)...)
+
+		// Plugins loaded by zsh plugin managers
+		if !fish {
+			for _, bundle := range s.parseBundles(line) {
+				s.scanBundle(bundle, outer, sourceFile, result, visited, depth)
 			}
 		}
 
@@ -121,79 +322,253 @@ func (s *Scanner) scanFile(filePath string, result *model.ScanResult, visited ma
 		if IsIncludeLine(line) {
 			includes := s.includeParser.ParseLine(line)
 			for _, inc := range includes {
-				sourceFile.Includes = append(sourceFile.Includes, inc.Path)
+				if sourcesLoopVariable(inc.Path, loopIncludes) {
+					continue // Expanded above
+				}
+
+				guard := sourceConditions(commands, func(arg string) bool { return arg == inc.Path }, outer)
 
 				// Try to resolve the included path
 				resolvedPath, ok := s.pathResolver.ResolvePath(inc.Path)
 				if !ok {
+					sourceFile.Includes = append(sourceFile.Includes, inc.Path)
 					result.UnresolvedPaths = append(result.UnresolvedPaths, inc.Path)
 					continue
 				}
 
-				// Recursively scan the included file
-				if err := s.scanFile(resolvedPath, result, visited, depth+1); err != nil {
-					result.Warnings = append(result.Warnings, fmt.Sprintf("Error scanning included file %s: %v", resolvedPath, err))
-				}
-
-				// Mark file as conditional if needed
-				if inc.Conditional {
-					if sf, exists := result.Files[resolvedPath]; exists {
-						sf.Conditional = true
+				if hasGlob(resolvedPath) {
+					// source reads the first match, the others become its arguments
+					matches := expandGlob(resolvedPath)
+					if len(matches) == 0 {
+						sourceFile.Includes = append(sourceFile.Includes, inc.Path)
+						continue
 					}
+					sourceFile.Includes = append(sourceFile.Includes, matches[0])
+					s.scanInclude(matches[0], guard, result, visited, depth)
+					continue
 				}
+
+				sourceFile.Includes = append(sourceFile.Includes, inc.Path)
+				s.scanInclude(resolvedPath, guard, result, visited, depth)
 			}
 		}
 
-		// Try to parse variable assignments for path resolution
-		if varName, value, ok := resolve.ParseVariableAssignment(line); ok {
-			// Update resolver with discovered variables
-			s.pathResolver.SetVariable(varName, value)
+		if function != "" && !open {
+			s.pathResolver.PopScope()
 		}
 	}
 
+	if fn, open := functionParser.Pending(); open {
+		s.pathResolver.PopScope()
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Unterminated function %s at %s:%d", fn.Name, canonPath, fn.Location.LineNum))
+	}
+
 	return nil
 }
 
-// DetectShell attempts to detect the user's shell
-func DetectShell() string {
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		return "bash" // Default to bash
+// trackVariables applies the assignments and unset statements of a line to the
+// resolver and records them. function is the function whose body the line is
+// part of, if any. Functions change variables when they run, not at startup,
+// so their assignments are confined to the function's scope.
+func (s *Scanner) trackVariables(line string, function string, location model.SourceLocation, result *model.ScanResult) {
+	for _, assignment := range resolve.ParseVariableAssignments(line) {
+		local := function != ""
+		var value string
+		var ok bool
+		switch {
+		case assignment.Name == "PATH" && local:
+			// Recorded, but the PATH commands are looked up on stays as it was
+			value, ok = s.pathResolver.Expand(assignment.Value)
+		case assignment.Name == "PATH":
+			value, ok = s.assignSearchPath(assignment.Value)
+		default:
+			value, ok = s.pathResolver.Assign(assignment.Name, assignment.Value, local)
+		}
+
+		record := model.VariableAssignment{
+			Name:     assignment.Name,
+			Value:    value,
+			RawValue: assignment.Value,
+			Resolved: ok,
+			Location: location,
+		}
+		if local {
+			record.Function = function
+		}
+		result.Variables = append(result.Variables, record)
 	}
 
-	// Extract shell name from path
-	shellName := filepath.Base(shell)
-	if shellName == "zsh" {
-		return "zsh"
+	for _, name := range resolve.ParseUnset(line) {
+		if function == "" {
+			s.pathResolver.UnsetVariable(name)
+		}
+		result.Variables = append(result.Variables, model.VariableAssignment{
+			Name:     name,
+			Unset:    true,
+			Function: function,
+			Location: location,
+		})
 	}
+}
 
-	return "bash"
+// scanInclude recursively scans a file sourced from the file at depth under conditions
+func (s *Scanner) scanInclude(resolvedPath string, conditions []model.Condition, result *model.ScanResult, visited map[string]bool, depth int) {
+	// Everything the file defines only exists if it's sourced
+	defer func(inherited []model.Condition, excluded bool) {
+		s.conditions, s.excluded = inherited, excluded
+	}(s.conditions, s.excluded)
+	s.excluded = s.excluded || s.isFalse(conditions, result.Shell)
+	s.conditions = joinConditions(s.conditions, conditions)
+
+	if err := s.scanFile(resolvedPath, result, visited, depth+1); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Error scanning included file %s: %v", resolvedPath, err))
+	}
+
+	// Mark file as conditional if needed
+	if len(conditions) > 0 {
+		if sf, exists := result.Files[resolvedPath]; exists {
+			sf.Conditional = true
+		}
+	}
 }
 
-// GetDefaultRootFiles returns the default root files for a given shell
-func GetDefaultRootFiles(shell string) []string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "~"
+// sourceConditions returns the conditions of the source command whose
+// argument matches, or fallback if no command on the line does
+func sourceConditions(commands []Command, matches func(arg string) bool, fallback []model.Condition) []model.Condition {
+	for _, cmd := range commands {
+		if len(cmd.Words) > 1 && isSourceWord(cmd.Words[0]) && matches(cmd.Words[1].Raw) {
+			return cmd.Conditions
+		}
+	}
+	return fallback
+}
+
+// sourcesLoopVariable reports whether an include path is a loop variable
+// already expanded to the files its loop iterates over
+func sourcesLoopVariable(path string, loopIncludes []LoopInclude) bool {
+	for _, inc := range loopIncludes {
+		if refersToVariable(path, inc.Variable) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAliasLine records the alias definitions and removals of a bash or zsh
+// command, found on the logical line at location
+func (s *Scanner) parseAliasLine(command string, location model.SourceLocation, conditions []model.Condition, sourceFile *model.SourceFile, result *model.ScanResult) {
+	// Try to parse as alias
+	if parser.IsAliasLine(command, result.Shell) {
+		for _, aliasDef := range s.aliasParser.ParseLine(command, location.FilePath, location.LineNum) {
+			aliasDef.Location = location
+			aliasDef.Conditions = conditions
+			s.addAlias(aliasDef, sourceFile, result)
+		}
+	}
+
+	// Try to parse as alias removal
+	if parser.IsUnaliasLine(command) {
+		for _, stmt := range s.aliasParser.ParseUnalias(command, location.FilePath, location.LineNum) {
+			stmt.Location = location
+			s.applyUnalias(stmt, conditions, result)
+		}
+	}
+}
+
+// parseFishLine records the alias and abbreviation definitions and erasures of
+// a fish command, found on the logical line at location
+func (s *Scanner) parseFishLine(command string, location model.SourceLocation, conditions []model.Condition, sourceFile *model.SourceFile, result *model.ScanResult) {
+	if !parser.IsFishAliasLine(command) {
+		return
+	}
+
+	for _, aliasDef := range s.fishParser.ParseLine(command, location.FilePath, location.LineNum) {
+		aliasDef.Location = location
+		aliasDef.Conditions = conditions
+		s.addAlias(aliasDef, sourceFile, result)
 	}
 
-	switch shell {
-	case "zsh":
-		return []string{
-			filepath.Join(homeDir, ".zshrc"),
-			filepath.Join(homeDir, ".zprofile"),
+	for _, stmt := range s.fishParser.ParseErase(command, location.FilePath, location.LineNum) {
+		stmt.Location = location
+		s.applyUnalias(stmt, conditions, result)
+	}
+}
+
+// addAlias records an alias definition, tagged with the plugin being scanned
+// and the conditions its file was sourced under
+func (s *Scanner) addAlias(aliasDef model.AliasDefinition, sourceFile *model.SourceFile, result *model.ScanResult) {
+	aliasDef.Plugin = s.plugin
+	aliasDef.Excluded = s.excluded || s.isFalse(aliasDef.Conditions, result.Shell)
+	aliasDef.Conditions = joinConditions(s.conditions, aliasDef.Conditions)
+	sourceFile.Aliases = append(sourceFile.Aliases, aliasDef)
+	result.AddAlias(aliasDef)
+}
+
+// applyUnalias records removal events for every alias an unalias statement
+// running under conditions removes
+func (s *Scanner) applyUnalias(stmt parser.Unalias, conditions []model.Condition, result *model.ScanResult) {
+	removal := model.AliasDefinition{
+		Location:   stmt.Location,
+		Conditions: joinConditions(s.conditions, conditions),
+		Excluded:   s.excluded || s.isFalse(conditions, result.Shell),
+	}
+	for _, name := range stmt.Names {
+		result.RemoveAlias(model.AliasKey(name, stmt.Type), removal)
+	}
+
+	if !stmt.All && len(stmt.Patterns) == 0 {
+		return
+	}
+
+	for key, entry := range result.Aliases {
+		// Suffix aliases are only affected by unalias -s and vice versa
+		if entry.Type.Namespace() != stmt.Type.Namespace() {
+			continue
 		}
-	case "bash":
-		return []string{
-			filepath.Join(homeDir, ".bashrc"),
-			filepath.Join(homeDir, ".bash_profile"),
-			filepath.Join(homeDir, ".profile"),
+		if stmt.All || matchesAnyPattern(entry.Name, stmt.Patterns) {
+			result.RemoveAlias(key, removal)
 		}
-	default:
-		return []string{
-			filepath.Join(homeDir, ".bashrc"),
+	}
+}
+
+// isFalse reports whether conditions are false on the target profile, as
+// evaluated where they appear. Conditions are never false without an evaluator.
+func (s *Scanner) isFalse(conditions []model.Condition, shell string) bool {
+	if s.evaluator == nil || len(conditions) == 0 {
+		return false
+	}
+
+	dialect := parser.DialectPOSIX
+	if shell == "fish" {
+		dialect = parser.DialectFish
+	}
+	return s.evaluator.Evaluate(conditions, dialect, s.pathResolver.ResolvePath) == profile.False
+}
+
+// matchesAnyPattern reports whether name matches one of the glob patterns
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
 		}
 	}
+	return false
+}
+
+// DetectShell attempts to detect the user's shell
+func DetectShell() string {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		return "bash" // Default to bash
+	}
+
+	// Extract shell name from path
+	shellName := filepath.Base(shell)
+	if shellName == "zsh" || shellName == "fish" {
+		return shellName
+	}
+
+	return "bash"
 }
 
 // FilterExistingFiles filters a list of files to only those that exist
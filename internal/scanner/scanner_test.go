@@ -0,0 +1,324 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oscar.rivas/falias/internal/model"
+)
+
+// writeFile writes a test fixture and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestScanMultipleAliasesPerLine(t *testing.T) {
+	dir := t.TempDir()
+	rc := writeFile(t, dir, ".bashrc", "alias ll='ls -l' la='ls -a' l='ls -CF'\n")
+
+	result, err := NewScanner().ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	for _, name := range []string{"ll", "la", "l"} {
+		entry, ok := result.Aliases[name]
+		if !ok {
+			t.Errorf("alias %q not found", name)
+			continue
+		}
+		if entry.ActiveLocation.LineNum != 1 {
+			t.Errorf("alias %q line = %d, want 1", name, entry.ActiveLocation.LineNum)
+		}
+	}
+
+	canon, _ := filepath.EvalSymlinks(rc)
+	if got := len(result.Files[canon].Aliases); got != 3 {
+		t.Errorf("file records %d aliases, want 3", got)
+	}
+}
+
+func TestScanMultiLineAlias(t *testing.T) {
+	dir := t.TempDir()
+	rc := writeFile(t, dir, ".bashrc", "# header\nalias deploy='make build &&\n  make push'\nalias gl='git log' \\\n  gs='git status'\n")
+
+	result, err := NewScanner().ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		wantValue string
+		wantStart int
+		wantEnd   int
+	}{
+		{"deploy", "make build &&\n  make push", 2, 3},
+		{"gl", "git log", 4, 5},
+		{"gs", "git status", 4, 5},
+	}
+
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.name]
+		if !ok {
+			t.Errorf("alias %q not found", tt.name)
+			continue
+		}
+		if entry.ActiveValue != tt.wantValue {
+			t.Errorf("alias %q value = %q, want %q", tt.name, entry.ActiveValue, tt.wantValue)
+		}
+		loc := entry.ActiveLocation
+		if loc.LineNum != tt.wantStart || loc.EndLine != tt.wantEnd {
+			t.Errorf("alias %q span = %d-%d, want %d-%d", tt.name, loc.LineNum, loc.EndLine, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestScanUnalias(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "later.sh", "unalias gco\nunalias -m 'd*'\n")
+	rc := writeFile(t, dir, ".bashrc", "alias gco='git checkout' gs='git status'\nalias dps='docker ps' dl='docker logs'\nsource "+filepath.Join(dir, "later.sh")+"\nalias dl='docker logs -f'\n")
+
+	result, err := NewScanner().ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		wantRemoved bool
+		wantEvents  int
+	}{
+		{"gco", true, 2},
+		{"gs", false, 1},
+		{"dps", true, 2},
+		{"dl", false, 3}, // Redefined after the removal
+	}
+
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.name]
+		if !ok {
+			t.Errorf("alias %q not found", tt.name)
+			continue
+		}
+		if entry.IsRemoved != tt.wantRemoved {
+			t.Errorf("alias %q removed = %v, want %v", tt.name, entry.IsRemoved, tt.wantRemoved)
+		}
+		if len(entry.Definitions) != tt.wantEvents {
+			t.Errorf("alias %q has %d events, want %d", tt.name, len(entry.Definitions), tt.wantEvents)
+		}
+	}
+
+	if result.Aliases["dl"].IsOverridden != true {
+		t.Errorf("alias dl should be overridden")
+	}
+}
+
+func TestScanSuffixAliasNamespace(t *testing.T) {
+	dir := t.TempDir()
+	rc := writeFile(t, dir, ".zshrc", "alias md=mdless\nalias -s md=glow\nunalias -s md\n")
+
+	result, err := NewScanner().ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	regular, ok := result.Aliases[model.AliasKey("md", model.AliasTypeNormal)]
+	if !ok || regular.IsRemoved || regular.ActiveValue != "mdless" {
+		t.Errorf("regular alias md = %+v, want active mdless", regular)
+	}
+
+	suffix, ok := result.Aliases[model.AliasKey("md", model.AliasTypeSuffix)]
+	if !ok || !suffix.IsRemoved || suffix.Type != model.AliasTypeSuffix {
+		t.Errorf("suffix alias md = %+v, want removed suffix alias", suffix)
+	}
+}
+
+func TestScanFunctions(t *testing.T) {
+	dir := t.TempDir()
+	extra := writeFile(t, dir, "funcs.sh", "mkcd() {\n  mkdir -p \"$1\" && cd \"$1\"\n}\n")
+	rc := writeFile(t, dir, ".bashrc", "function mkcd { mkdir \"$1\"; }\nsource "+extra+"\ngcb() { git checkout -b \"$1\"; }\n")
+
+	result, err := NewScanner().ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	mkcd, ok := result.Functions["mkcd"]
+	if !ok {
+		t.Fatalf("function mkcd not found")
+	}
+	if !mkcd.IsOverridden || len(mkcd.Definitions) != 2 {
+		t.Errorf("function mkcd has %d definitions, want 2 with override", len(mkcd.Definitions))
+	}
+	if loc := mkcd.ActiveLocation; loc.LineNum != 1 || loc.EndLine != 3 {
+		t.Errorf("function mkcd active span = %d-%d, want 1-3", loc.LineNum, loc.EndLine)
+	}
+
+	if _, ok := result.Functions["gcb"]; !ok {
+		t.Errorf("function gcb not found")
+	}
+}
+
+func TestScanFish(t *testing.T) {
+	dir := t.TempDir()
+	extra := writeFile(t, dir, "aliases.fish", "alias gs 'git status'\nabbr -a gco git checkout\n")
+	rc := writeFile(t, dir, "config.fish", "source "+extra+"\nalias gco='git commit'\nabbr -e gco\nfunction mkcd\n  mkdir -p $argv\nend\n")
+	fn := writeFile(t, dir, "functions/ll.fish", "function ll --description 'alias ll=ls -l'\n  ls -l $argv\nend\n")
+
+	result, err := NewScanner().ScanShellFiles("fish", []string{rc, fn})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	if gs := result.Aliases["gs"]; gs == nil || gs.ActiveValue != "git status" {
+		t.Errorf("alias gs = %+v, want git status", gs)
+	}
+
+	// The abbreviation and the alias share a name but live in different namespaces
+	if gco := result.Aliases[model.AliasKey("gco", model.AliasTypeNormal)]; gco == nil || gco.IsRemoved {
+		t.Errorf("alias gco = %+v, want active alias", gco)
+	}
+	if abbr := result.Aliases[model.AliasKey("gco", model.AliasTypeAbbreviation)]; abbr == nil || !abbr.IsRemoved {
+		t.Errorf("abbreviation gco = %+v, want erased abbreviation", abbr)
+	}
+
+	if ll := result.Aliases["ll"]; ll == nil || ll.ActiveValue != "ls -l" {
+		t.Errorf("alias ll = %+v, want saved alias ls -l", ll)
+	}
+	if _, ok := result.Functions["ll"]; ok {
+		t.Errorf("saved alias ll should not be listed as a function")
+	}
+	if _, ok := result.Functions["mkcd"]; !ok {
+		t.Errorf("function mkcd not found")
+	}
+}
+
+func TestScanGlobIncludes(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "aliases.d/a.sh", "alias a1=one\n")
+	b := writeFile(t, dir, "aliases.d/b.sh", "alias b1=two\n")
+	writeFile(t, dir, "aliases.d/.hidden.sh", "alias hidden=yes\n")
+	writeFile(t, dir, "first.d/1.sh", "alias first=1\n")
+	writeFile(t, dir, "first.d/2.sh", "alias second=2\n")
+	rc := writeFile(t, dir, ".bashrc",
+		"for f in "+dir+"/aliases.d/*.sh; do\n  source \"$f\"\ndone\nsource "+dir+"/first.d/*.sh\n")
+
+	result, err := NewScanner().ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	for _, name := range []string{"a1", "b1", "first"} {
+		if _, ok := result.Aliases[name]; !ok {
+			t.Errorf("alias %q not found", name)
+		}
+	}
+	for _, name := range []string{"hidden", "second"} {
+		if _, ok := result.Aliases[name]; ok {
+			t.Errorf("alias %q should not be found", name)
+		}
+	}
+	if len(result.UnresolvedPaths) != 0 {
+		t.Errorf("UnresolvedPaths = %v, want none", result.UnresolvedPaths)
+	}
+
+	canon, _ := filepath.EvalSymlinks(rc)
+	includes := result.Files[canon].Includes
+	if len(includes) != 3 || includes[0] != a || includes[1] != b {
+		t.Errorf("Includes = %v, want %s, %s and the first match", includes, a, b)
+	}
+}
+
+func TestScanRelativeIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "dotfiles/lib/git.sh", "alias gs='git status'\n")
+	writeFile(t, dir, "dotfiles/lib/nested.sh", "source ./git.sh\n")
+	writeFile(t, dir, "dotfiles/extra.sh", "alias x=y\n")
+	rc := writeFile(t, dir, "dotfiles/bashrc",
+		"source lib/nested.sh\nsource \"$(dirname \"${BASH_SOURCE[0]}\")/extra.sh\"\n")
+
+	result, err := NewScanner().ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	for _, name := range []string{"gs", "x"} {
+		if _, ok := result.Aliases[name]; !ok {
+			t.Errorf("alias %q not found", name)
+		}
+	}
+	if len(result.UnresolvedPaths) != 0 {
+		t.Errorf("UnresolvedPaths = %v, want none", result.UnresolvedPaths)
+	}
+}
+
+func TestScanVariableScopes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "global/a.sh", "alias global=yes\n")
+	writeFile(t, dir, "local/a.sh", "alias local=yes\n")
+	rc := writeFile(t, dir, ".bashrc", "D="+dir+"/global\n"+
+		"load() {\n  local D="+dir+"/local\n  source $D/a.sh\n}\n"+
+		"source $D/a.sh\n"+
+		"unset D\nsource $D/a.sh\n")
+
+	result, err := NewScanner().ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	for _, name := range []string{"global", "local"} {
+		if _, ok := result.Aliases[name]; !ok {
+			t.Errorf("alias %q not found", name)
+		}
+	}
+
+	// After unset, $D no longer resolves
+	if len(result.UnresolvedPaths) != 1 || result.UnresolvedPaths[0] != "$D/a.sh" {
+		t.Errorf("UnresolvedPaths = %v, want [$D/a.sh]", result.UnresolvedPaths)
+	}
+
+	if len(result.Variables) != 3 {
+		t.Fatalf("Variables = %+v, want 3 records", result.Variables)
+	}
+	local := result.Variables[1]
+	if local.Function != "load" || local.Location.LineNum != 3 {
+		t.Errorf("local D recorded as %+v, want local to load at line 3", local)
+	}
+	if unset := result.Variables[2]; !unset.Unset || unset.Location.LineNum != 7 {
+		t.Errorf("unset D recorded as %+v, want unset at line 7", unset)
+	}
+
+	// Functions that aren't called leave the startup values alone
+	writeFile(t, dir, "startup/x.sh", "alias startup=yes\n")
+	writeFile(t, dir, "called/x.sh", "alias called=yes\n")
+	rc = writeFile(t, dir, ".zshrc", "D="+dir+"/startup\n"+
+		"f() { D="+dir+"/called; }\n"+
+		"g() {\n  unset D\n}\n"+
+		"source $D/x.sh\n")
+
+	result, err = NewScanner().ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+	if _, ok := result.Aliases["startup"]; !ok {
+		t.Errorf("alias startup not found, $D was changed by a function body")
+	}
+	if _, ok := result.Aliases["called"]; ok {
+		t.Errorf("alias called found, want $D to keep its startup value")
+	}
+	for _, v := range result.Variables[1:] {
+		if v.Function == "" {
+			t.Errorf("assignment in a function recorded as %+v, want local to it", v)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+// Matches zsh's path array, tied to PATH: path=(...) or path+=(...)
+var pathArrayPattern = regexp.MustCompile(`^path(\+?)=(?:\(((?s).*)\))?$`)
+
+// Matches the zsh glob qualifiers often added to path entries, which may
+// contain a slash: ~/bin(N-/)
+var pathQualifierPattern = regexp.MustCompile(`\([N\-/.@*]+\)$`)
+
+// searchPath returns the directories of PATH as the files scanned so far left it
+func (s *Scanner) searchPath() []string {
+	value, ok := s.pathResolver.LookupVariable("PATH")
+	if !ok {
+		return nil
+	}
+
+	dirs := make([]string, 0)
+	for _, dir := range filepath.SplitList(value) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// setSearchPath sets PATH to dirs and records the assignment
+func (s *Scanner) setSearchPath(dirs []string, raw string, location model.SourceLocation, result *model.ScanResult) {
+	value := strings.Join(dirs, string(filepath.ListSeparator))
+	s.pathResolver.SetVariable("PATH", value)
+	result.Variables = append(result.Variables, model.VariableAssignment{
+		Name:     "PATH",
+		Value:    value,
+		RawValue: raw,
+		Resolved: true,
+		Location: location,
+	})
+}
+
+// assignSearchPath evaluates a PATH=value assignment and returns the new PATH.
+// The value is expanded before it is split into entries, since an expansion
+// may add separators: ~/bin${PATH:+:$PATH}. If it can't be expanded, the
+// entries are evaluated one by one, so that one entry that can't be resolved,
+// such as $(brew --prefix)/bin, doesn't lose the others.
+func (s *Scanner) assignSearchPath(raw string) (string, bool) {
+	var dirs []string
+	if expanded, ok := s.pathResolver.Expand(raw); ok {
+		dirs = s.pathDirs(expanded)
+	} else {
+		for _, entry := range splitPathList(raw) {
+			if expanded, ok := s.pathResolver.Expand(entry); ok {
+				dirs = append(dirs, s.pathDirs(expanded)...)
+			}
+		}
+	}
+
+	value := strings.Join(dirs, string(filepath.ListSeparator))
+	s.pathResolver.SetVariable("PATH", value)
+	return value, true
+}
+
+// expandPathEntries expands entries of PATH as written, dropping those that
+// can't be resolved. An entry may expand to several directories: $PATH.
+func (s *Scanner) expandPathEntries(entries []string) []string {
+	dirs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = pathQualifierPattern.ReplaceAllString(entry, "")
+		switch entry {
+		case "":
+			continue
+		case "$path", "${path}", "$PATH", "${PATH}", `"$PATH"`:
+			dirs = append(dirs, s.searchPath()...)
+			continue
+		}
+
+		if expanded, ok := s.pathResolver.ExpandWord(entry); ok {
+			dirs = append(dirs, s.pathDirs(expanded)...)
+		}
+	}
+	return dirs
+}
+
+// pathDirs splits an expanded PATH value into its directories. The shell
+// looks up empty and relative entries from the current directory, which isn't
+// known at startup, so they are dropped, as are entries holding special
+// parameters such as $1.
+func (s *Scanner) pathDirs(value string) []string {
+	dirs := make([]string, 0)
+	for _, dir := range filepath.SplitList(value) {
+		// A tilde is expanded after every separator of an assignment: PATH=~/bin:~/.local/bin
+		if strings.HasPrefix(dir, "~") {
+			expanded, ok := s.pathResolver.Expand(dir)
+			if !ok {
+				continue
+			}
+			dir = expanded
+		}
+		if !filepath.IsAbs(dir) || strings.Contains(dir, "$") {
+			continue
+		}
+		dirs = append(dirs, filepath.Clean(dir))
+	}
+	return dirs
+}
+
+// splitPathList splits a PATH value as written on the separators outside of
+// expansions and command substitutions
+func splitPathList(value string) []string {
+	var entries []string
+	depth, start := 0, 0
+	backquoted := false
+
+	for i := 0; i < len(value); i++ {
+		switch ch := value[i]; {
+		case ch == '\\':
+			i++
+		case ch == '`':
+			backquoted = !backquoted
+		case ch == '$' && i+1 < len(value) && (value[i+1] == '{' || value[i+1] == '('):
+			depth++
+			i++
+		case (ch == '{' || ch == '(') && depth > 0:
+			depth++
+		case (ch == '}' || ch == ')') && depth > 0:
+			depth--
+		case ch == ':' && depth == 0 && !backquoted:
+			entries = append(entries, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(entries, value[start:])
+}
+
+// trackSearchPath applies the changes to PATH of a logical line that aren't
+// plain assignments: zsh's path array, and fish's set PATH, fish_user_paths
+// and fish_add_path
+func (s *Scanner) trackSearchPath(line string, commands []Command, fish bool, location model.SourceLocation, result *model.ScanResult) {
+	if !fish {
+		words, _ := parser.Tokenize(strings.TrimSpace(line))
+		for _, cmd := range parser.SplitCommands(words) {
+			matches := pathArrayPattern.FindStringSubmatch(cmd[0].Raw)
+			if matches == nil || cmd[0].Kind != parser.WordLiteral {
+				continue
+			}
+
+			var entries []string
+			for _, item := range strings.Split(matches[2], "\n") {
+				item, _, _ = strings.Cut(item, "#")
+				entries = append(entries, strings.Fields(item)...)
+			}
+			dirs := s.expandPathEntries(entries)
+			if matches[1] == "+" {
+				dirs = append(s.searchPath(), dirs...)
+			}
+			s.setSearchPath(dirs, strings.Join(entries, " "), location, result)
+		}
+		return
+	}
+
+	for _, cmd := range commands {
+		if len(cmd.Words) < 2 {
+			continue
+		}
+
+		var options, entries []string
+		for _, w := range cmd.Words[1:] {
+			if strings.HasPrefix(w.Value, "-") && len(entries) == 0 {
+				options = append(options, w.Value)
+			} else {
+				entries = append(entries, w.Raw)
+			}
+		}
+		appended := hasOption(options, "-a", "--append")
+		prepended := hasOption(options, "-p", "--prepend")
+
+		switch cmd.Words[0].Value {
+		case "set":
+			// set -gx PATH dir $PATH, set -U fish_user_paths dir $fish_user_paths
+			if len(entries) < 1 || (entries[0] != "PATH" && entries[0] != "fish_user_paths") || hasOption(options, "-e", "--erase", "-q", "--query") {
+				continue
+			}
+			if entries[0] == "fish_user_paths" {
+				// fish puts its user paths before PATH
+				entries = withoutEntry(entries, "$fish_user_paths")
+				prepended = !appended
+			}
+			entries = entries[1:]
+		case "fish_add_path":
+			// Prepends unless told to append
+			prepended = !appended
+		default:
+			continue
+		}
+
+		dirs := s.expandPathEntries(entries)
+		switch {
+		case appended:
+			dirs = append(s.searchPath(), dirs...)
+		case prepended:
+			dirs = append(dirs, s.searchPath()...)
+		}
+		s.setSearchPath(dirs, strings.Join(entries, " "), location, result)
+	}
+}
+
+// withoutEntry returns entries without those equal to entry
+func withoutEntry(entries []string, entry string) []string {
+	kept := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e != entry {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// hasOption reports whether options contain one of names, or a short option
+// letter of names in a group such as -gx
+func hasOption(options []string, names ...string) bool {
+	for _, option := range options {
+		for _, name := range names {
+			if option == name {
+				return true
+			}
+			if len(name) == 2 && !strings.HasPrefix(option, "--") && strings.Contains(option[1:], name[1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findExecutable returns the first executable file called name in dirs
+func findExecutable(dirs []string, name string) (string, bool) {
+	if name == "" || strings.ContainsRune(name, '/') {
+		return "", false
+	}
+
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0 {
+			return path, true
+		}
+	}
+	return "", false
+}
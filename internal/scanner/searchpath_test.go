@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanSearchPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		shell    string
+		rc       string
+		wantDirs []string // Relative to the test directory
+	}{
+		{
+			name:     "export",
+			shell:    "bash",
+			rc:       "export PATH=\"$HOME/bin:$(brew --prefix)/bin:$PATH\"\n",
+			wantDirs: []string{"home/bin", "usr/bin"},
+		},
+		{
+			name:     "expansion adding a separator",
+			shell:    "bash",
+			rc:       "PATH=$HOME/bin${PATH:+:$PATH}\n",
+			wantDirs: []string{"home/bin", "usr/bin"},
+		},
+		{
+			name:     "relative and empty entries",
+			shell:    "bash",
+			rc:       "export PATH=\"bin::$HOME/bin:./node_modules/.bin:$PATH:\"\n",
+			wantDirs: []string{"home/bin", "usr/bin"},
+		},
+		{
+			name:     "zsh path array",
+			shell:    "zsh",
+			rc:       "path=(\n  $HOME/bin(N-/)\n  $path\n)\npath+=($HOME/.cargo/bin)\n",
+			wantDirs: []string{"home/bin", "usr/bin", "home/.cargo/bin"},
+		},
+		{
+			name:     "zsh path array with a relative entry",
+			shell:    "zsh",
+			rc:       "path=(node_modules/.bin $HOME/bin $path)\n",
+			wantDirs: []string{"home/bin", "usr/bin"},
+		},
+		{
+			name:     "fish",
+			shell:    "fish",
+			rc:       "fish_add_path $HOME/.cargo/bin\nset -gx PATH $PATH $HOME/go/bin\nset -U fish_user_paths $HOME/bin $fish_user_paths\n",
+			wantDirs: []string{"home/bin", "home/.cargo/bin", "usr/bin", "home/go/bin"},
+		},
+		{
+			name:     "set in a function",
+			shell:    "bash",
+			rc:       "venv() {\n  PATH=\"$1/bin:$PATH\"\n}\n",
+			wantDirs: []string{"usr/bin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			rc := writeFile(t, dir, "rc", tt.rc)
+
+			s := NewScanner()
+			s.SetEnvironment(map[string]string{"HOME": dir + "/home", "PATH": dir + "/usr/bin"})
+			result, err := s.ScanShellFiles(tt.shell, []string{rc})
+			if err != nil {
+				t.Fatalf("ScanShellFiles() error = %v", err)
+			}
+
+			want := make([]string, len(tt.wantDirs))
+			for i, d := range tt.wantDirs {
+				want[i] = filepath.Join(dir, d)
+			}
+			if strings.Join(result.SearchPath, ":") != strings.Join(want, ":") {
+				t.Errorf("SearchPath = %v, want %v", result.SearchPath, want)
+			}
+		})
+	}
+}
+
+func TestFunctionSearchPathRecorded(t *testing.T) {
+	dir := t.TempDir()
+	rc := writeFile(t, dir, "rc", "venv() {\n  PATH=\"$HOME/venv/bin:$PATH\"\n}\n")
+
+	s := NewScanner()
+	s.SetEnvironment(map[string]string{"HOME": dir + "/home", "PATH": dir + "/usr/bin"})
+	result, err := s.ScanShellFiles("bash", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	if len(result.Variables) != 1 || result.Variables[0].Name != "PATH" || result.Variables[0].Function != "venv" {
+		t.Fatalf("Variables = %+v, want the PATH assignment of venv", result.Variables)
+	}
+	if want := dir + "/home/venv/bin:" + dir + "/usr/bin"; result.Variables[0].Value != want {
+		t.Errorf("PATH value = %q, want %q", result.Variables[0].Value, want)
+	}
+	if want := filepath.Join(dir, "usr/bin"); strings.Join(result.SearchPath, ":") != want {
+		t.Errorf("SearchPath = %v, want %v", result.SearchPath, want)
+	}
+}
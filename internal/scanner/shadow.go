@@ -0,0 +1,28 @@
+package scanner
+
+import "github.com/oscar.rivas/falias/internal/model"
+
+// detectShadows flags the active aliases whose name is a reserved word or
+// builtin of the shell, a function found in the scan, or an executable on the
+// PATH the scanned files assemble
+func (s *Scanner) detectShadows(result *model.ScanResult) {
+	for _, entry := range result.Aliases {
+		if entry.Type == model.AliasTypeSuffix || entry.IsRemoved || entry.IsExcluded {
+			continue
+		}
+
+		if kind, severity, ok := ShellCommand(result.Shell, entry.Name); ok {
+			entry.Shadows = append(entry.Shadows, model.Shadow{Kind: kind, Severity: severity})
+		}
+
+		// The alias is expanded before the shell looks the function up
+		if fn, ok := result.Functions[entry.Name]; ok {
+			location := fn.ActiveLocation
+			entry.Shadows = append(entry.Shadows, model.Shadow{Kind: model.ShadowFunction, Severity: model.SeverityMedium, Location: &location})
+		}
+
+		if path, ok := findExecutable(result.SearchPath, entry.Name); ok {
+			entry.Shadows = append(entry.Shadows, model.Shadow{Kind: model.ShadowExecutable, Severity: model.SeverityLow, Path: path})
+		}
+	}
+}
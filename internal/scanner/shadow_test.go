@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"os"
+	"testing"
+
+	"github.com/oscar.rivas/falias/internal/model"
+)
+
+// writeExecutable creates an executable file in dir
+func writeExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := writeFile(t, dir, name, "#!/bin/sh\n")
+	if err := os.Chmod(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDetectShadows(t *testing.T) {
+	dir := t.TempDir()
+	ls := writeExecutable(t, dir, "bin/ls")
+	writeExecutable(t, dir, "usr/bin/ls")
+	grep := writeExecutable(t, dir, "usr/bin/grep")
+	writeFile(t, dir, "usr/bin/notes", "not executable\n")
+	rc := writeFile(t, dir, ".zshrc", `export PATH="`+dir+`/bin:$PATH"
+alias ls='ls -G'
+alias grep=rg
+alias notes='vim ~/notes'
+alias -s ls=cat
+alias ll='ls -l'
+alias rm='rm -i'
+unalias rm
+alias cd=z
+alias history='history 1'
+alias if=echo
+mkcd() { mkdir -p "$1" && cd "$1"; }
+alias mkcd='mkdir -p'
+`)
+	writeExecutable(t, dir, "usr/bin/rm")
+
+	s := NewScanner()
+	s.SetEnvironment(map[string]string{"PATH": dir + "/usr/bin"})
+	result, err := s.ScanShellFiles("zsh", []string{rc})
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		key          string
+		wantKind     model.ShadowKind
+		wantSeverity model.Severity
+		wantPath     string
+	}{
+		{key: "ls", wantKind: model.ShadowExecutable, wantSeverity: model.SeverityLow, wantPath: ls},
+		{key: "grep", wantKind: model.ShadowExecutable, wantSeverity: model.SeverityLow, wantPath: grep},
+		{key: "notes"},
+		{key: "*.ls"},
+		{key: "ll"},
+		{key: "rm"}, // Removed
+		{key: "cd", wantKind: model.ShadowBuiltin, wantSeverity: model.SeverityHigh},
+		{key: "history", wantKind: model.ShadowBuiltin, wantSeverity: model.SeverityLow},
+		{key: "if", wantKind: model.ShadowKeyword, wantSeverity: model.SeverityHigh},
+		{key: "mkcd", wantKind: model.ShadowFunction, wantSeverity: model.SeverityMedium, wantPath: rc},
+	}
+	for _, tt := range tests {
+		entry, ok := result.Aliases[tt.key]
+		if !ok {
+			t.Fatalf("alias %q not found", tt.key)
+		}
+		if tt.wantKind == "" {
+			if len(entry.Shadows) != 0 {
+				t.Errorf("alias %q shadows %+v, want nothing", tt.key, entry.Shadows)
+			}
+			continue
+		}
+		if len(entry.Shadows) == 0 {
+			t.Errorf("alias %q shadows nothing, want a %s", tt.key, tt.wantKind)
+			continue
+		}
+
+		got := entry.Shadows[0]
+		path := got.Path
+		if got.Location != nil {
+			path = got.Location.FilePath
+		}
+		if got.Kind != tt.wantKind || got.Severity != tt.wantSeverity || path != tt.wantPath {
+			t.Errorf("alias %q shadows %s %q (%s), want %s %q (%s)", tt.key, got.Kind, path, got.Severity, tt.wantKind, tt.wantPath, tt.wantSeverity)
+		}
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	tests := []struct {
+		shell        string
+		name         string
+		wantKind     model.ShadowKind
+		wantSeverity model.Severity
+	}{
+		{shell: "bash", name: "exec", wantKind: model.ShadowBuiltin, wantSeverity: model.SeverityHigh},
+		{shell: "bash", name: "echo", wantKind: model.ShadowBuiltin, wantSeverity: model.SeverityMedium},
+		{shell: "bash", name: "shopt", wantKind: model.ShadowBuiltin, wantSeverity: model.SeverityHigh},
+		{shell: "bash", name: "setopt"},
+		{shell: "zsh", name: "setopt", wantKind: model.ShadowBuiltin, wantSeverity: model.SeverityHigh},
+		{shell: "zsh", name: "repeat", wantKind: model.ShadowKeyword, wantSeverity: model.SeverityHigh},
+		{shell: "zsh", name: "which", wantKind: model.ShadowBuiltin, wantSeverity: model.SeverityLow},
+		{shell: "fish", name: "string", wantKind: model.ShadowBuiltin, wantSeverity: model.SeverityHigh},
+		{shell: "fish", name: "and", wantKind: model.ShadowKeyword, wantSeverity: model.SeverityHigh},
+		{shell: "fish", name: "alias"}, // A function in fish
+		{shell: "bash", name: "ls"},
+	}
+
+	for _, tt := range tests {
+		kind, severity, ok := ShellCommand(tt.shell, tt.name)
+		if ok != (tt.wantKind != "") || kind != tt.wantKind || severity != tt.wantSeverity {
+			t.Errorf("ShellCommand(%q, %q) = %q, %q, %v, want %q, %q", tt.shell, tt.name, kind, severity, ok, tt.wantKind, tt.wantSeverity)
+		}
+	}
+}
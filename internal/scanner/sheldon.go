@@ -0,0 +1,184 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/oscar.rivas/falias/internal/model"
+	"github.com/oscar.rivas/falias/internal/parser"
+)
+
+var (
+	// Matches a TOML table header, capturing the plugin of [plugins.name]
+	sheldonTablePattern = regexp.MustCompile(`^\[\s*plugins\.(?:"([^"]+)"|'([^']+)'|([A-Za-z0-9_-]+))\s*\]$`)
+
+	// Matches a quoted TOML string
+	tomlStringPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"|'([^']*)'`)
+)
+
+// sheldonPlugin holds the keys of a [plugins.<name>] table of plugins.toml
+type sheldonPlugin struct {
+	name   string
+	values map[string][]string // String values, or the items of string arrays
+}
+
+// isSheldonSource reports whether the command of a dynamic include prints
+// sheldon's plugin script: sheldon source, sheldon --quiet source
+func isSheldonSource(include model.DynamicInclude) bool {
+	if include.Tool != "sheldon" {
+		return false
+	}
+
+	words, _ := parser.Tokenize(include.Command)
+	commands := parser.SplitCommands(words)
+	if len(commands) == 0 {
+		return false
+	}
+	// Skip to the subcommand, past prefixes and global options
+	args := commands[0]
+	for len(args) > 0 && filepath.Base(args[0].Value) != "sheldon" {
+		args = args[1:]
+	}
+	for _, w := range args[min(1, len(args)):] {
+		if !strings.HasPrefix(w.Value, "-") {
+			return w.Value == "source"
+		}
+	}
+	return false
+}
+
+// sheldonBundles returns the plugins declared in sheldon's plugins.toml:
+//
+//	[plugins.zsh-autosuggestions]
+//	github = "zsh-users/zsh-autosuggestions"
+//	use = ["{{ name }}.zsh"]
+//
+// The config is read from $SHELDON_CONFIG_FILE, by default
+// ~/.config/sheldon/plugins.toml, and repositories are cloned into
+// $SHELDON_DATA_DIR/repos/<host>/<user>/<repo>.
+func (s *Scanner) sheldonBundles() []Bundle {
+	configDir := s.managerDir("SHELDON_CONFIG_DIR", "${XDG_CONFIG_HOME:-$HOME/.config}/sheldon")
+	configFile, ok := s.pathResolver.ResolvePath("$SHELDON_CONFIG_FILE")
+	if !ok {
+		configFile = filepath.Join(configDir, "plugins.toml")
+	}
+	lines, err := s.fileReader.ReadLines(configFile)
+	if err != nil {
+		return nil
+	}
+
+	dataDir := s.managerDir("SHELDON_DATA_DIR", "${XDG_DATA_HOME:-$HOME/.local/share}/sheldon")
+
+	bundles := make([]Bundle, 0)
+	for _, plugin := range parseSheldonPlugins(lines) {
+		if apply := plugin.values["apply"]; len(apply) > 0 && !containsAny(apply, "source", "defer") {
+			continue // Only added to $fpath or $PATH
+		}
+
+		bundle := Bundle{Manager: "sheldon", Name: plugin.name}
+		for _, use := range plugin.values["use"] {
+			bundle.Use = append(bundle.Use, strings.ReplaceAll(strings.ReplaceAll(use, "{{ name }}", plugin.name), "{{name}}", plugin.name))
+		}
+
+		switch {
+		case len(plugin.values["github"]) > 0:
+			bundle.Dir = filepath.Join(dataDir, "repos", "github.com", plugin.values["github"][0])
+		case len(plugin.values["git"]) > 0:
+			bundle.Dir = filepath.Join(dataDir, "repos", repoPath(plugin.values["git"][0]))
+		case len(plugin.values["remote"]) > 0:
+			bundle.Dir = filepath.Join(dataDir, "downloads", repoPath(plugin.values["remote"][0]))
+		case len(plugin.values["local"]) > 0:
+			dir, ok := s.pathResolver.ResolvePath(plugin.values["local"][0])
+			if !ok {
+				continue
+			}
+			bundle.Dir = dir
+		default:
+			continue // Inline plugins have no files
+		}
+
+		if len(bundle.Use) == 0 {
+			// sheldon's default globs, the first one matching wins
+			bundle.Use = firstMatchingGlob(bundle.Dir, []string{
+				plugin.name + ".plugin.zsh", plugin.name + ".zsh", plugin.name + ".sh", plugin.name + ".zsh-theme",
+				"*.plugin.zsh", "*.zsh", "*.sh", "*.zsh-theme",
+			})
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles
+}
+
+// parseSheldonPlugins reads the plugin tables of a plugins.toml file. Only
+// string and string array values are kept, which is all plugins use.
+func parseSheldonPlugins(lines []string) []sheldonPlugin {
+	plugins := make([]sheldonPlugin, 0)
+
+	var current *sheldonPlugin
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if matches := sheldonTablePattern.FindStringSubmatch(line); matches != nil {
+				plugins = append(plugins, sheldonPlugin{
+					name:   matches[1] + matches[2] + matches[3],
+					values: make(map[string][]string),
+				})
+				current = &plugins[len(plugins)-1]
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			continue
+		}
+
+		// Arrays may span several lines
+		value = strings.TrimSpace(value)
+		for strings.HasPrefix(value, "[") && !strings.Contains(stripTOMLStrings(value), "]") && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(lines[i])
+		}
+
+		strs := make([]string, 0)
+		for _, matches := range tomlStringPattern.FindAllStringSubmatch(value, -1) {
+			strs = append(strs, matches[1]+matches[2])
+		}
+		current.values[strings.Trim(strings.TrimSpace(key), `"'`)] = strs
+	}
+
+	return plugins
+}
+
+// stripTOMLStrings removes the quoted strings of a TOML value
+func stripTOMLStrings(value string) string {
+	return tomlStringPattern.ReplaceAllString(value, "")
+}
+
+// firstMatchingGlob returns the first glob matching a file in dir
+func firstMatchingGlob(dir string, globs []string) []string {
+	for _, glob := range globs {
+		if len(expandGlob(filepath.Join(dir, glob))) > 0 {
+			return []string{glob}
+		}
+	}
+	return nil
+}
+
+// containsAny reports whether values contains one of wanted
+func containsAny(values []string, wanted ...string) bool {
+	for _, value := range values {
+		for _, w := range wanted {
+			if value == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/oscar.rivas/falias/internal/resolve"
+)
+
+// Session is the kind of shell session whose startup files are scanned
+type Session string
+
+const (
+	SessionLogin          Session = "login"           // Interactive login shell, e.g. a new terminal on macOS
+	SessionInteractive    Session = "interactive"     // Interactive shell that is not a login shell
+	SessionNonInteractive Session = "non-interactive" // Shell running a script or a -c command
+)
+
+// ParseSession validates a session name
+func ParseSession(name string) (Session, bool) {
+	switch session := Session(name); session {
+	case SessionLogin, SessionInteractive, SessionNonInteractive:
+		return session, true
+	default:
+		return "", false
+	}
+}
+
+// DefaultSession returns the session a new terminal usually starts for shell
+func DefaultSession(shell string) Session {
+	if shell == "zsh" {
+		// zsh is the macOS default, where terminals start login shells
+		return SessionLogin
+	}
+	return SessionInteractive
+}
+
+// systemDirs lists the directories searched for system-wide zsh startup files,
+// in order of preference. Debian based systems keep them in /etc/zsh.
+var systemDirs = []string{"/etc/zsh", "/etc"}
+
+// StartupFile is a file a shell may read when it starts
+type StartupFile struct {
+	Path    string
+	Skipped bool // Never read in the session, unless sourced by another file
+}
+
+// GetDefaultRootFiles returns the startup files shell reads for a session, in
+// the order the shell reads them. env holds the variables the shell starts
+// with, as given to Scanner.SetEnvironment; ZDOTDIR and BASH_ENV move or add
+// startup files.
+func GetDefaultRootFiles(shell string, session Session, env map[string]string) []string {
+	files := make([]string, 0)
+	for _, file := range StartupSequence(shell, session, env) {
+		if !file.Skipped {
+			files = append(files, file.Path)
+		}
+	}
+	return files
+}
+
+// StartupSequence returns every startup file of shell in the order the shell
+// considers them, marking those the session skips. env holds the variables
+// the shell starts with.
+func StartupSequence(shell string, session Session, env map[string]string) []StartupFile {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "~"
+	}
+
+	resolver := resolve.NewPathResolver()
+	resolver.SetEnvironment(env)
+
+	switch shell {
+	case "zsh":
+		return zshStartupFiles(homeDir, session, resolver)
+	case "bash":
+		return bashStartupFiles(homeDir, session, resolver)
+	case "fish":
+		return readAll(fishRootFiles(resolver))
+	default:
+		return readAll([]string{filepath.Join(homeDir, ".bashrc")})
+	}
+}
+
+// bashStartupFiles returns the files bash considers for a session:
+//
+//	/etc/profile        login shells
+//	~/.bash_profile     login shells, first one found of the three
+//	~/.bash_login
+//	~/.profile
+//	/etc/bash.bashrc    interactive non-login shells
+//	~/.bashrc           interactive non-login shells
+//	$BASH_ENV           non-interactive shells
+func bashStartupFiles(homeDir string, session Session, resolver *resolve.PathResolver) []StartupFile {
+	login := session == SessionLogin
+	interactive := session == SessionInteractive
+
+	files := []StartupFile{{Path: "/etc/profile", Skipped: !login}}
+
+	// A login shell stops at the first personal profile it finds
+	found := false
+	for _, name := range []string{".bash_profile", ".bash_login", ".profile"} {
+		path := filepath.Join(homeDir, name)
+		read := login && !found && FileExists(path)
+		found = found || read
+		files = append(files, StartupFile{Path: path, Skipped: !read})
+	}
+
+	files = append(files,
+		StartupFile{Path: "/etc/bash.bashrc", Skipped: !interactive},
+		StartupFile{Path: filepath.Join(homeDir, ".bashrc"), Skipped: !interactive},
+	)
+
+	if env, _ := resolver.LookupVariable("BASH_ENV"); env != "" {
+		files = append(files, StartupFile{Path: env, Skipped: session != SessionNonInteractive})
+	}
+
+	return files
+}
+
+// zshStartupFiles returns the files zsh considers for a session:
+//
+//	zshenv    always
+//	zprofile  login shells
+//	zshrc     interactive shells
+//	zlogin    login shells
+//
+// Each system-wide file is read before the user's file of the same name in $ZDOTDIR
+func zshStartupFiles(homeDir string, session Session, resolver *resolve.PathResolver) []StartupFile {
+	// .zshenv is looked up in $ZDOTDIR as set in the environment, and may
+	// itself move $ZDOTDIR for the files read after it
+	envDir, _ := resolver.LookupVariable("ZDOTDIR")
+	if envDir == "" {
+		envDir = homeDir
+	}
+	zdotdir := zshenvDotDir(filepath.Join(envDir, ".zshenv"), envDir, resolver)
+
+	login := session == SessionLogin
+	interactive := login || session == SessionInteractive
+
+	return []StartupFile{
+		{Path: zshSystemFile("zshenv")},
+		{Path: filepath.Join(envDir, ".zshenv")},
+		{Path: zshSystemFile("zprofile"), Skipped: !login},
+		{Path: filepath.Join(zdotdir, ".zprofile"), Skipped: !login},
+		{Path: zshSystemFile("zshrc"), Skipped: !interactive},
+		{Path: filepath.Join(zdotdir, ".zshrc"), Skipped: !interactive},
+		{Path: zshSystemFile("zlogin"), Skipped: !login},
+		{Path: filepath.Join(zdotdir, ".zlogin"), Skipped: !login},
+	}
+}
+
+// readAll returns startup files that are read in every session
+func readAll(paths []string) []StartupFile {
+	files := make([]StartupFile, len(paths))
+	for i, path := range paths {
+		files[i] = StartupFile{Path: path}
+	}
+	return files
+}
+
+// zshSystemFile returns the path of a system-wide zsh startup file
+func zshSystemFile(name string) string {
+	for _, dir := range systemDirs {
+		path := filepath.Join(dir, name)
+		if FileExists(path) {
+			return path
+		}
+	}
+	return filepath.Join(systemDirs[len(systemDirs)-1], name)
+}
+
+// zshenvDotDir returns the $ZDOTDIR in effect after reading a .zshenv file,
+// or fallback if the file doesn't set it to a path that can be resolved.
+// resolver holds the environment and is changed by the file's assignments.
+func zshenvDotDir(zshenv string, fallback string, resolver *resolve.PathResolver) string {
+	lines, err := NewFileReader().ReadLines(zshenv)
+	if err != nil {
+		return fallback
+	}
+
+	dir := fallback
+	for _, logical := range JoinLogicalLines(lines) {
+		for _, assignment := range resolve.ParseVariableAssignments(logical.Text) {
+			if _, ok := resolver.Assign(assignment.Name, assignment.Value, false); !ok || assignment.Name != "ZDOTDIR" {
+				continue
+			}
+			if resolved, ok := resolver.ResolvePath("$ZDOTDIR"); ok {
+				dir = resolved
+			}
+		}
+	}
+
+	return dir
+}
+
+// fishRootFiles returns the files fish reads at startup, in order: conf.d
+// snippets sorted by name, config.fish, then the autoloaded function files
+func fishRootFiles(resolver *resolve.PathResolver) []string {
+	configDir, _ := resolver.ResolvePath("$XDG_CONFIG_HOME")
+	fishDir := filepath.Join(configDir, "fish")
+
+	// Glob only fails on malformed patterns and returns sorted matches
+	confd, _ := filepath.Glob(filepath.Join(fishDir, "conf.d", "*.fish"))
+	functions, _ := filepath.Glob(filepath.Join(fishDir, "functions", "*.fish"))
+
+	files := append(confd, filepath.Join(fishDir, "config.fish"))
+	return append(files, functions...)
+}
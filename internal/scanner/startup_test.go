@@ -0,0 +1,176 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// userFiles returns the files in paths that live under dir, relative to it
+func userFiles(paths []string, dir string) []string {
+	var rel []string
+	for _, path := range paths {
+		if r, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = append(rel, r)
+		}
+	}
+	return rel
+}
+
+func TestZshRootFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		zshenv  string
+		env     map[string]string
+		session Session
+		want    []string
+	}{
+		{
+			name:    "login",
+			session: SessionLogin,
+			want:    []string{".zshenv", ".zprofile", ".zshrc", ".zlogin"},
+		},
+		{
+			name:    "interactive",
+			session: SessionInteractive,
+			want:    []string{".zshenv", ".zshrc"},
+		},
+		{
+			name:    "non-interactive",
+			session: SessionNonInteractive,
+			want:    []string{".zshenv"},
+		},
+		{
+			name:    "ZDOTDIR set in .zshenv",
+			zshenv:  "export ZDOTDIR=\"$HOME/.config/zsh\"\n",
+			session: SessionInteractive,
+			want:    []string{".zshenv", ".config/zsh/.zshrc"},
+		},
+		{
+			name:    "ZDOTDIR set in the environment",
+			env:     map[string]string{"ZDOTDIR": "~/.config/zsh"},
+			session: SessionLogin,
+			want:    []string{".config/zsh/.zshenv", ".config/zsh/.zprofile", ".config/zsh/.zshrc", ".config/zsh/.zlogin"},
+		},
+		{
+			name:    "ZDOTDIR from the environment moved by .zshenv",
+			zshenv:  "ZDOTDIR=$ZDOTDIR/rc\n",
+			env:     map[string]string{"ZDOTDIR": "~/dots"},
+			session: SessionInteractive,
+			want:    []string{"dots/.zshenv", "dots/rc/.zshrc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("ZDOTDIR", home+"/ignored") // Only the scan environment counts
+			if tt.zshenv != "" {
+				dir := home
+				if zdotdir, ok := tt.env["ZDOTDIR"]; ok {
+					dir = filepath.Join(home, strings.TrimPrefix(zdotdir, "~/"))
+				}
+				writeFile(t, dir, ".zshenv", tt.zshenv)
+			}
+
+			files := GetDefaultRootFiles("zsh", tt.session, tt.env)
+			if got := userFiles(files, home); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("GetDefaultRootFiles() user files = %v, want %v", got, tt.want)
+			}
+
+			// System-wide files come first
+			if !strings.HasSuffix(files[0], "zshenv") || strings.HasPrefix(files[0], home) {
+				t.Errorf("GetDefaultRootFiles()[0] = %s, want system zshenv", files[0])
+			}
+		})
+	}
+}
+
+func TestBashStartupSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		env      map[string]string
+		session  Session
+		wantRead []string
+	}{
+		{
+			name:     "login reads the first profile only",
+			files:    []string{".bash_login", ".profile", ".bashrc"},
+			session:  SessionLogin,
+			wantRead: []string{".bash_login"},
+		},
+		{
+			name:     "login prefers .bash_profile",
+			files:    []string{".bash_profile", ".bash_login", ".profile"},
+			session:  SessionLogin,
+			wantRead: []string{".bash_profile"},
+		},
+		{
+			name:     "interactive",
+			files:    []string{".bash_profile", ".bashrc"},
+			session:  SessionInteractive,
+			wantRead: []string{".bashrc"},
+		},
+		{
+			name:    "non-interactive",
+			files:   []string{".bash_profile", ".bashrc"},
+			session: SessionNonInteractive,
+		},
+		{
+			name:     "non-interactive reads BASH_ENV",
+			files:    []string{".bashrc", ".bash_env"},
+			env:      map[string]string{"BASH_ENV": "~/.bash_env"},
+			session:  SessionNonInteractive,
+			wantRead: []string{".bash_env"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("BASH_ENV", home+"/.bashrc") // Only the scan environment counts
+			for _, name := range tt.files {
+				writeFile(t, home, name, "")
+			}
+
+			got := userFiles(GetDefaultRootFiles("bash", tt.session, tt.env), home)
+			if strings.Join(got, ",") != strings.Join(tt.wantRead, ",") {
+				t.Errorf("GetDefaultRootFiles() user files = %v, want %v", got, tt.wantRead)
+			}
+		})
+	}
+}
+
+func TestScanRecordsSkippedStartupFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeFile(t, home, ".bashrc", "alias ll='ls -l'\n")
+	writeFile(t, home, ".profile", "alias ll='ls -la'\n")
+	profile := writeFile(t, home, ".bash_profile", "alias gs='git status'\n")
+
+	s := NewScanner()
+	s.SetSession(SessionLogin)
+	result, err := s.ScanShellFiles("bash", GetDefaultRootFiles("bash", SessionLogin, nil))
+	if err != nil {
+		t.Fatalf("ScanShellFiles() error = %v", err)
+	}
+
+	if _, ok := result.Aliases["ll"]; ok {
+		t.Errorf("alias ll from files a login shell never reads should not be found")
+	}
+
+	for _, name := range []string{".bashrc", ".profile"} {
+		canon, _ := filepath.EvalSymlinks(filepath.Join(home, name))
+		if sf, ok := result.Files[canon]; !ok || !sf.Skipped {
+			t.Errorf("%s = %+v, want skipped", name, sf)
+		}
+	}
+
+	canon, _ := filepath.EvalSymlinks(profile)
+	if sf := result.Files[canon]; sf == nil || sf.Skipped {
+		t.Errorf(".bash_profile = %+v, want scanned", sf)
+	}
+}
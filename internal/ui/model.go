@@ -19,6 +19,13 @@ const (
 	ViewByFile
 	ViewOverridden
 	ViewGlobals
+	ViewSuffixes
+	ViewRemoved
+	ViewDead
+	ViewFunctions
+	ViewDynamic
+
+	viewModeCount = iota // Number of view modes, keep last
 )
 
 func (v ViewMode) String() string {
@@ -31,6 +38,16 @@ func (v ViewMode) String() string {
 		return "Overridden"
 	case ViewGlobals:
 		return "Globals"
+	case ViewSuffixes:
+		return "Suffixes"
+	case ViewRemoved:
+		return "Removed"
+	case ViewDead:
+		return "Dead"
+	case ViewFunctions:
+		return "Functions"
+	case ViewDynamic:
+		return "Dynamic"
 	default:
 		return "All"
 	}
@@ -66,6 +83,14 @@ type Model struct {
 	displayedAliases []*model.AliasEntry
 	allAliases       []*model.AliasEntry
 
+	// Filtered/displayed functions, listed in the Functions view
+	displayedFunctions []*model.FunctionEntry
+	allFunctions       []*model.FunctionEntry
+
+	// Filtered/displayed dynamic includes, listed in the Dynamic view
+	displayedDynamic []model.DynamicInclude
+	allDynamic       []model.DynamicInclude
+
 	// Components
 	searchInput textinput.Model
 	spinner     spinner.Model
@@ -77,32 +102,33 @@ type Model struct {
 	rootFiles []string
 }
 
-// NewModel creates a new TUI model with a theme
-func NewModel(shell string, rootFiles []string, theme config.Theme) Model {
+// NewModel creates a new TUI model with a theme, scanning with s
+func NewModel(s *scanner.Scanner, shell string, rootFiles []string, theme config.Theme) Model {
 	// Create text input for search
 	ti := textinput.New()
 	ti.Placeholder = "Search aliases..."
 	ti.CharLimit = 100
 
 	// Create spinner
-	s := spinner.New()
-	s.Spinner = spinner.Dot
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
 
 	// Create styles from theme
 	styles := NewStyles(theme)
-	s.Style = styles.SpinnerStyle
+	sp.Style = styles.SpinnerStyle
 
 	return Model{
-		scanner:          scanner.NewScanner(),
+		scanner:          s,
 		shell:            shell,
 		rootFiles:        rootFiles,
 		searchInput:      ti,
-		spinner:          s,
+		spinner:          sp,
 		keys:             defaultKeyMap(),
 		styles:           styles,
 		viewMode:         ViewAll,
 		displayedAliases: make([]*model.AliasEntry, 0),
 		allAliases:       make([]*model.AliasEntry, 0),
+		allFunctions:     make([]*model.FunctionEntry, 0),
 		scanning:         true,
 		themeList:        config.GetAvailableThemes(),
 		currentThemeName: theme.Name,
@@ -159,6 +185,8 @@ type windowSizeMsg struct {
 func (m *Model) filterAliases() {
 	if m.scanResult == nil {
 		m.displayedAliases = make([]*model.AliasEntry, 0)
+		m.displayedFunctions = make([]*model.FunctionEntry, 0)
+		m.displayedDynamic = make([]model.DynamicInclude, 0)
 		return
 	}
 
@@ -185,6 +213,37 @@ func (m *Model) filterAliases() {
 		}
 		filtered = temp
 
+	case ViewSuffixes:
+		temp := make([]*model.AliasEntry, 0)
+		for _, alias := range filtered {
+			if alias.Type == model.AliasTypeSuffix {
+				temp = append(temp, alias)
+			}
+		}
+		filtered = temp
+
+	case ViewRemoved:
+		temp := make([]*model.AliasEntry, 0)
+		for _, alias := range filtered {
+			if alias.IsRemoved {
+				temp = append(temp, alias)
+			}
+		}
+		filtered = temp
+
+	case ViewDead:
+		temp := make([]*model.AliasEntry, 0)
+		for _, alias := range filtered {
+			if alias.MissingCommand != "" {
+				temp = append(temp, alias)
+			}
+		}
+		filtered = temp
+
+	case ViewFunctions, ViewDynamic:
+		// Functions or dynamic includes are listed instead of aliases
+		filtered = make([]*model.AliasEntry, 0)
+
 	case ViewByFile:
 		// TODO: Implement grouping by file
 		// For now, just show all
@@ -209,16 +268,71 @@ func (m *Model) filterAliases() {
 	}
 
 	m.displayedAliases = filtered
+	m.filterFunctions(searchTerm)
+	m.filterDynamic(searchTerm)
 
 	// Adjust cursor if needed
-	if m.cursor >= len(m.displayedAliases) {
-		m.cursor = len(m.displayedAliases) - 1
+	if m.cursor >= m.listLen() {
+		m.cursor = m.listLen() - 1
 	}
 	if m.cursor < 0 {
 		m.cursor = 0
 	}
 }
 
+// filterFunctions filters the function list based on the search term
+func (m *Model) filterFunctions(searchTerm string) {
+	if m.viewMode != ViewFunctions {
+		m.displayedFunctions = make([]*model.FunctionEntry, 0)
+		return
+	}
+
+	filtered := m.allFunctions
+	if searchTerm != "" {
+		temp := make([]*model.FunctionEntry, 0)
+		for _, fn := range filtered {
+			if strings.Contains(strings.ToLower(fn.Name), searchTerm) ||
+				strings.Contains(strings.ToLower(fn.ActiveBody), searchTerm) {
+				temp = append(temp, fn)
+			}
+		}
+		filtered = temp
+	}
+
+	m.displayedFunctions = filtered
+}
+
+// filterDynamic filters the dynamic include list based on the search term
+func (m *Model) filterDynamic(searchTerm string) {
+	if m.viewMode != ViewDynamic {
+		m.displayedDynamic = make([]model.DynamicInclude, 0)
+		return
+	}
+
+	filtered := make([]model.DynamicInclude, 0, len(m.allDynamic))
+	for _, dyn := range m.allDynamic {
+		if searchTerm == "" ||
+			strings.Contains(strings.ToLower(dyn.Tool), searchTerm) ||
+			strings.Contains(strings.ToLower(dyn.Command), searchTerm) ||
+			strings.Contains(strings.ToLower(strings.Join(dyn.Aliases, " ")), searchTerm) {
+			filtered = append(filtered, dyn)
+		}
+	}
+
+	m.displayedDynamic = filtered
+}
+
+// listLen returns the number of entries in the list for the current view mode
+func (m *Model) listLen() int {
+	switch m.viewMode {
+	case ViewFunctions:
+		return len(m.displayedFunctions)
+	case ViewDynamic:
+		return len(m.displayedDynamic)
+	}
+	return len(m.displayedAliases)
+}
+
 // getCurrentAlias returns the currently selected alias
 func (m *Model) getCurrentAlias() *model.AliasEntry {
 	if len(m.displayedAliases) == 0 || m.cursor >= len(m.displayedAliases) {
@@ -227,9 +341,25 @@ func (m *Model) getCurrentAlias() *model.AliasEntry {
 	return m.displayedAliases[m.cursor]
 }
 
+// getCurrentFunction returns the currently selected function in the Functions view
+func (m *Model) getCurrentFunction() *model.FunctionEntry {
+	if len(m.displayedFunctions) == 0 || m.cursor >= len(m.displayedFunctions) {
+		return nil
+	}
+	return m.displayedFunctions[m.cursor]
+}
+
+// getCurrentDynamic returns the currently selected dynamic include in the Dynamic view
+func (m *Model) getCurrentDynamic() *model.DynamicInclude {
+	if len(m.displayedDynamic) == 0 || m.cursor >= len(m.displayedDynamic) {
+		return nil
+	}
+	return &m.displayedDynamic[m.cursor]
+}
+
 // cycleViewMode cycles to the next view mode
 func (m *Model) cycleViewMode() {
-	m.viewMode = (m.viewMode + 1) % 4
+	m.viewMode = (m.viewMode + 1) % viewModeCount
 	m.filterAliases()
 	m.cursor = 0
 }
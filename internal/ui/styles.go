@@ -25,9 +25,13 @@ type Styles struct {
 
 	// Badge styles
 	GlobalBadgeStyle      lipgloss.Style
+	SuffixBadgeStyle      lipgloss.Style
+	AbbrBadgeStyle        lipgloss.Style
 	OverriddenBadgeStyle  lipgloss.Style
 	MissingBadgeStyle     lipgloss.Style
+	RemovedBadgeStyle     lipgloss.Style
 	ConditionalBadgeStyle lipgloss.Style
+	ShadowBadgeStyle      lipgloss.Style
 
 	// Modal styles
 	ModalBoxStyle    lipgloss.Style
@@ -111,6 +115,18 @@ func NewStyles(theme config.Theme) Styles {
 			Background(theme.Secondary).
 			Foreground(lipgloss.Color("0")),
 
+		SuffixBadgeStyle: lipgloss.NewStyle().
+			Padding(0, 1).
+			Bold(true).
+			Background(theme.Primary).
+			Foreground(lipgloss.Color("0")),
+
+		AbbrBadgeStyle: lipgloss.NewStyle().
+			Padding(0, 1).
+			Bold(true).
+			Background(theme.Success).
+			Foreground(lipgloss.Color("0")),
+
 		OverriddenBadgeStyle: lipgloss.NewStyle().
 			Padding(0, 1).
 			Bold(true).
@@ -123,12 +139,24 @@ func NewStyles(theme config.Theme) Styles {
 			Background(theme.Error).
 			Foreground(lipgloss.Color("255")),
 
+		RemovedBadgeStyle: lipgloss.NewStyle().
+			Padding(0, 1).
+			Bold(true).
+			Background(theme.Error).
+			Foreground(lipgloss.Color("255")),
+
 		ConditionalBadgeStyle: lipgloss.NewStyle().
 			Padding(0, 1).
 			Bold(true).
 			Background(theme.Muted).
 			Foreground(lipgloss.Color("255")),
 
+		ShadowBadgeStyle: lipgloss.NewStyle().
+			Padding(0, 1).
+			Bold(true).
+			Background(theme.Warning).
+			Foreground(lipgloss.Color("0")),
+
 		// Modal styles
 		ModalBoxStyle: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -44,8 +44,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return strings.ToLower(m.allAliases[i].Name) < strings.ToLower(m.allAliases[j].Name)
 		})
 
+		m.allFunctions = msg.result.GetFunctionsSorted()
+		m.allDynamic = msg.result.GetDynamicIncludes()
+
 		m.filterAliases()
-		m.statusMessage = fmt.Sprintf("Found %d aliases", len(m.allAliases))
+		m.statusMessage = fmt.Sprintf("Found %d aliases and %d functions", len(m.allAliases), len(m.allFunctions))
 		return m, nil
 
 	case scanErrorMsg:
@@ -163,7 +166,7 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case msg.String() == "down" || msg.String() == "j":
-		if m.cursor < len(m.displayedAliases)-1 {
+		if m.cursor < m.listLen()-1 {
 			m.cursor++
 		}
 
@@ -173,13 +176,25 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, textinput.Blink
 
 	case msg.String() == "enter":
-		if len(m.displayedAliases) > 0 {
+		if m.listLen() > 0 {
 			m.showDetails = true
 		}
 
 	case msg.String() == "c":
 		// Copy value
-		if alias := m.getCurrentAlias(); alias != nil {
+		if dyn := m.getCurrentDynamic(); dyn != nil {
+			if err := clipboard.WriteAll(dyn.Command); err == nil {
+				m.statusMessage = "Copied command to clipboard"
+			} else {
+				m.errorMessage = "Failed to copy to clipboard"
+			}
+		} else if fn := m.getCurrentFunction(); fn != nil {
+			if err := clipboard.WriteAll(fn.ActiveBody); err == nil {
+				m.statusMessage = "Copied function to clipboard"
+			} else {
+				m.errorMessage = "Failed to copy to clipboard"
+			}
+		} else if alias := m.getCurrentAlias(); alias != nil {
 			if err := clipboard.WriteAll(alias.ActiveValue); err == nil {
 				m.statusMessage = "Copied value to clipboard"
 			} else {
@@ -189,7 +204,19 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case msg.String() == "n":
 		// Copy name
-		if alias := m.getCurrentAlias(); alias != nil {
+		if dyn := m.getCurrentDynamic(); dyn != nil {
+			if err := clipboard.WriteAll(dyn.Tool); err == nil {
+				m.statusMessage = "Copied tool to clipboard"
+			} else {
+				m.errorMessage = "Failed to copy to clipboard"
+			}
+		} else if fn := m.getCurrentFunction(); fn != nil {
+			if err := clipboard.WriteAll(fn.Name); err == nil {
+				m.statusMessage = "Copied name to clipboard"
+			} else {
+				m.errorMessage = "Failed to copy to clipboard"
+			}
+		} else if alias := m.getCurrentAlias(); alias != nil {
 			if err := clipboard.WriteAll(alias.Name); err == nil {
 				m.statusMessage = "Copied name to clipboard"
 			} else {
@@ -198,9 +225,24 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case msg.String() == "p":
-		// Copy full alias definition
-		if alias := m.getCurrentAlias(); alias != nil {
-			fullDef := fmt.Sprintf("alias %s='%s'", alias.Name, alias.ActiveValue)
+		// Copy full alias definition; a function body already is its full definition
+		if dyn := m.getCurrentDynamic(); dyn != nil {
+			if err := clipboard.WriteAll(dyn.Location.RawLine); err == nil {
+				m.statusMessage = "Copied full line to clipboard"
+			} else {
+				m.errorMessage = "Failed to copy to clipboard"
+			}
+		} else if fn := m.getCurrentFunction(); fn != nil {
+			if err := clipboard.WriteAll(fn.ActiveBody); err == nil {
+				m.statusMessage = "Copied full definition to clipboard"
+			} else {
+				m.errorMessage = "Failed to copy to clipboard"
+			}
+		} else if alias := m.getCurrentAlias(); alias != nil {
+			fullDef := fmt.Sprintf("alias %s%s=%s", aliasFlag(alias.Type), alias.Name, singleQuote(alias.ActiveValue))
+			if alias.Type == model.AliasTypeAbbreviation {
+				fullDef = fmt.Sprintf("abbr -a %s %s", alias.Name, fishQuote(alias.ActiveValue))
+			}
 			if err := clipboard.WriteAll(fullDef); err == nil {
 				m.statusMessage = "Copied full definition to clipboard"
 			} else {
@@ -230,3 +272,26 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	return m, tea.Batch(cmds...)
 }
+
+// aliasFlag returns the alias option needed to recreate an alias of the given type
+func aliasFlag(aliasType model.AliasType) string {
+	switch aliasType {
+	case model.AliasTypeGlobal:
+		return "-g "
+	case model.AliasTypeSuffix:
+		return "-s "
+	default:
+		return ""
+	}
+}
+
+// singleQuote wraps a value in single quotes for bash and zsh, closing the
+// quote around each embedded ' so values like it's stay valid shell
+func singleQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fishQuote wraps a value in fish single quotes, where \ and \' are escapes
+func fishQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+}
@@ -95,10 +95,23 @@ func (m Model) renderHeader() string {
 	}
 
 	count := ""
-	if m.scanResult != nil {
+	if m.scanResult != nil && m.viewMode == ViewDynamic {
+		count = m.styles.CountStyle.Render(fmt.Sprintf("%d/%d dynamic includes",
+			len(m.displayedDynamic),
+			len(m.allDynamic)))
+	} else if m.scanResult != nil && m.viewMode == ViewFunctions {
+		count = m.styles.CountStyle.Render(fmt.Sprintf("%d/%d functions",
+			len(m.displayedFunctions),
+			len(m.allFunctions)))
+	} else if m.scanResult != nil {
 		count = m.styles.CountStyle.Render(fmt.Sprintf("%d/%d aliases",
 			len(m.displayedAliases),
 			len(m.allAliases)))
+
+		// Aliases generated by eval "$(tool init)" can't be listed, see the Dynamic view
+		if len(m.allDynamic) > 0 {
+			count += m.styles.MutedStyle.Render(fmt.Sprintf(" +%d dynamic", len(m.allDynamic)))
+		}
 	}
 
 	viewMode := m.styles.ShellInfoStyle.Render(fmt.Sprintf("[%s]", m.viewMode.String()))
@@ -126,14 +139,72 @@ func (m Model) renderSearch() string {
 	return label + input
 }
 
-// renderList renders the alias list
+// renderList renders the alias list, or the function or dynamic include list
+// in the Functions and Dynamic views
 func (m Model) renderList() string {
+	switch m.viewMode {
+	case ViewFunctions:
+		return m.renderFunctionList()
+	case ViewDynamic:
+		return m.renderDynamicList()
+	}
+
 	if len(m.displayedAliases) == 0 {
 		return m.styles.MutedStyle.Render("No aliases found")
 	}
 
 	var s strings.Builder
 
+	start, end := m.visibleRange(len(m.displayedAliases))
+	for i := start; i < end; i++ {
+		alias := m.displayedAliases[i]
+		line := m.renderListItem(alias, i == m.cursor)
+		s.WriteString(line)
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
+// renderFunctionList renders the function list
+func (m Model) renderFunctionList() string {
+	if len(m.displayedFunctions) == 0 {
+		return m.styles.MutedStyle.Render("No functions found")
+	}
+
+	var s strings.Builder
+
+	start, end := m.visibleRange(len(m.displayedFunctions))
+	for i := start; i < end; i++ {
+		fn := m.displayedFunctions[i]
+		line := m.renderFunctionItem(fn, i == m.cursor)
+		s.WriteString(line)
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
+// renderDynamicList renders the dynamic include list
+func (m Model) renderDynamicList() string {
+	if len(m.displayedDynamic) == 0 {
+		return m.styles.MutedStyle.Render("No dynamic includes found")
+	}
+
+	var s strings.Builder
+
+	start, end := m.visibleRange(len(m.displayedDynamic))
+	for i := start; i < end; i++ {
+		line := m.renderDynamicItem(m.displayedDynamic[i], i == m.cursor)
+		s.WriteString(line)
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
+// visibleRange returns the scroll window [start, end) for a list of total items
+func (m Model) visibleRange(total int) (int, int) {
 	// Calculate how many items we can show
 	maxItems := m.height - 10 // Rough calculation
 	if maxItems < 5 {
@@ -146,22 +217,15 @@ func (m Model) renderList() string {
 		start = 0
 	}
 	end := start + maxItems
-	if end > len(m.displayedAliases) {
-		end = len(m.displayedAliases)
+	if end > total {
+		end = total
 		start = end - maxItems
 		if start < 0 {
 			start = 0
 		}
 	}
 
-	for i := start; i < end; i++ {
-		alias := m.displayedAliases[i]
-		line := m.renderListItem(alias, i == m.cursor)
-		s.WriteString(line)
-		s.WriteString("\n")
-	}
-
-	return s.String()
+	return start, end
 }
 
 // renderListItem renders a single list item
@@ -182,9 +246,33 @@ func (m Model) renderListItem(alias *model.AliasEntry, selected bool) string {
 	if alias.Type == model.AliasTypeGlobal {
 		badges = append(badges, m.styles.GlobalBadgeStyle.Render("global"))
 	}
+	if alias.Type == model.AliasTypeSuffix {
+		badges = append(badges, m.styles.SuffixBadgeStyle.Render("suffix"))
+	}
+	if alias.Type == model.AliasTypeAbbreviation {
+		badges = append(badges, m.styles.AbbrBadgeStyle.Render("abbr"))
+	}
 	if alias.IsOverridden {
 		badges = append(badges, m.styles.OverriddenBadgeStyle.Render("overridden"))
 	}
+	if alias.IsRemoved {
+		badges = append(badges, m.styles.RemovedBadgeStyle.Render("removed"))
+	}
+	if alias.IsExcluded {
+		badges = append(badges, m.styles.ConditionalBadgeStyle.Render("excluded"))
+	}
+	if alias.InCycle {
+		badges = append(badges, m.styles.MissingBadgeStyle.Render("cycle"))
+	}
+	if alias.MissingCommand != "" {
+		badges = append(badges, m.styles.MissingBadgeStyle.Render("dead"))
+	}
+	switch alias.ShadowSeverity() {
+	case model.SeverityHigh:
+		badges = append(badges, m.styles.MissingBadgeStyle.Render("shadows"))
+	case model.SeverityLow, model.SeverityMedium:
+		badges = append(badges, m.styles.ShadowBadgeStyle.Render("shadows"))
+	}
 
 	// File info
 	file := m.styles.AliasFileStyle.Render(filepath.Base(alias.ActiveLocation.FilePath))
@@ -202,6 +290,66 @@ func (m Model) renderListItem(alias *model.AliasEntry, selected bool) string {
 	return m.styles.ListItemStyle.Render("  " + content)
 }
 
+// renderFunctionItem renders a single function list item
+func (m Model) renderFunctionItem(fn *model.FunctionEntry, selected bool) string {
+	name := m.styles.AliasNameStyle.Render(fn.Name + "()")
+
+	// Line span instead of the (multi-line) body
+	loc := fn.ActiveLocation
+	span := m.styles.AliasValueStyle.Render(fmt.Sprintf("lines %s", loc.LineRange()))
+
+	var badges []string
+	if fn.IsOverridden {
+		badges = append(badges, m.styles.OverriddenBadgeStyle.Render("overridden"))
+	}
+
+	file := m.styles.AliasFileStyle.Render(filepath.Base(loc.FilePath))
+
+	content := fmt.Sprintf("%-20s %-45s %s %s",
+		name,
+		span,
+		strings.Join(badges, " "),
+		file)
+
+	if selected {
+		return m.styles.SelectedItemStyle.Render("▸ " + content)
+	}
+	return m.styles.ListItemStyle.Render("  " + content)
+}
+
+// renderDynamicItem renders a single dynamic include in the Dynamic view
+func (m Model) renderDynamicItem(dyn model.DynamicInclude, selected bool) string {
+	name := m.styles.AliasNameStyle.Render(dyn.Tool)
+
+	maxValueLen := 40
+	command := dyn.Command
+	if len(command) > maxValueLen {
+		command = command[:maxValueLen-3] + "..."
+	}
+	value := m.styles.AliasValueStyle.Render(command)
+
+	var badges []string
+	if !dyn.Known {
+		badges = append(badges, m.styles.ShadowBadgeStyle.Render("unknown"))
+	}
+	if len(dyn.Aliases) > 0 {
+		badges = append(badges, m.styles.GlobalBadgeStyle.Render(strings.Join(dyn.Aliases, " ")))
+	}
+
+	file := m.styles.AliasFileStyle.Render(filepath.Base(dyn.Location.FilePath))
+
+	content := fmt.Sprintf("%-20s %-45s %s %s",
+		name,
+		value,
+		strings.Join(badges, " "),
+		file)
+
+	if selected {
+		return m.styles.SelectedItemStyle.Render("▸ " + content)
+	}
+	return m.styles.ListItemStyle.Render("  " + content)
+}
+
 // renderFooter renders the footer with keybindings
 func (m Model) renderFooter() string {
 	keys := []string{
@@ -237,6 +385,13 @@ func (m Model) renderStatus() string {
 
 // renderDetails renders the details modal
 func (m Model) renderDetails() string {
+	switch m.viewMode {
+	case ViewFunctions:
+		return m.renderFunctionDetails()
+	case ViewDynamic:
+		return m.renderDynamicDetails()
+	}
+
 	alias := m.getCurrentAlias()
 	if alias == nil {
 		return "No alias selected"
@@ -258,33 +413,118 @@ func (m Model) renderDetails() string {
 	content.WriteString(m.styles.ModalValueStyle.Render(string(alias.Type)))
 	content.WriteString("\n")
 
+	// Plugin
+	if alias.Plugin != "" {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Plugin: "))
+		content.WriteString(m.styles.ModalValueStyle.Render(alias.Plugin))
+		content.WriteString("\n")
+	}
+
 	// Value
 	content.WriteString(m.styles.ModalLabelStyle.Render("Value: "))
 	content.WriteString(m.styles.ModalValueStyle.Render(alias.ActiveValue))
-	content.WriteString("\n\n")
+	content.WriteString("\n")
+
+	// Expansion chain, when the value uses other aliases
+	if len(alias.Expansion) > 1 {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Expansion:"))
+		content.WriteString("\n")
+		for i, step := range alias.Expansion {
+			text := step.Text
+			if i == len(alias.Expansion)-1 {
+				text = m.styles.ModalActiveStyle.Render(text)
+			}
+			content.WriteString(fmt.Sprintf("  %d. %s %s %s\n", i+1,
+				m.styles.MutedStyle.Render(step.Alias), m.styles.MutedStyle.Render("→"), text))
+		}
+	}
+
+	// Condition
+	if len(alias.Conditions) > 0 {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Condition: "))
+		content.WriteString(m.styles.ModalValueStyle.Render(model.ConditionText(alias.Conditions)))
+		content.WriteString("\n")
+	}
+	// Shadowed commands
+	for _, shadow := range alias.Shadows {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Shadows: "))
+		content.WriteString(m.styles.ModalValueStyle.Render(describeShadow(alias.Name, shadow)))
+		content.WriteString("\n")
+	}
+	// Cycle, listing where each member is defined
+	if alias.InCycle && m.scanResult != nil {
+		if cycle := m.scanResult.CycleOf(alias.Name); cycle != nil {
+			content.WriteString(m.styles.ModalLabelStyle.Render("Cycle: "))
+			content.WriteString(m.styles.ModalValueStyle.Render(cycle.Path()))
+			content.WriteString("\n")
+			for _, member := range cycle.Members {
+				content.WriteString(fmt.Sprintf("  %s %s\n", member.Name,
+					m.styles.MutedStyle.Render(fmt.Sprintf("%s:%s", member.Location.FilePath, member.Location.LineRange()))))
+			}
+		}
+	} else if alias.SelfReference {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Self-reference: "))
+		content.WriteString(m.styles.ModalValueStyle.Render("uses itself, which the shell doesn't expand again"))
+		content.WriteString("\n")
+	}
+	if alias.MissingCommand != "" {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Missing command: "))
+		content.WriteString(m.styles.ModalValueStyle.Render(alias.MissingCommand + " is not on PATH, a builtin, a function or another alias"))
+		content.WriteString("\n")
+	}
+	if alias.IsExcluded {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Excluded: "))
+		content.WriteString(m.styles.ModalValueStyle.Render("conditions are false on the target profile"))
+		content.WriteString("\n")
+	}
+
+	// Removal
+	if alias.IsRemoved {
+		// The unalias that removed it, which later excluded definitions may follow
+		var removal model.SourceLocation
+		for _, def := range alias.Definitions {
+			if def.Removed && !def.Excluded {
+				removal = def.Location
+			}
+		}
+		content.WriteString(m.styles.ModalLabelStyle.Render("Removed: "))
+		content.WriteString(m.styles.ModalValueStyle.Render(fmt.Sprintf("unalias at %s:%s", removal.FilePath, removal.LineRange())))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
 
 	// Definitions
 	if len(alias.Definitions) == 1 {
 		content.WriteString(m.styles.ModalLabelStyle.Render("Defined in:"))
 		content.WriteString("\n")
 		loc := alias.ActiveLocation
-		content.WriteString("  " + m.styles.ModalValueStyle.Render(fmt.Sprintf("%s:%d", loc.FilePath, loc.LineNum)))
+		content.WriteString("  " + m.styles.ModalValueStyle.Render(fmt.Sprintf("%s:%s", loc.FilePath, loc.LineRange())))
 		content.WriteString("\n\n")
 		content.WriteString(m.styles.HelpStyle.Render(fmt.Sprintf("Open: code -g %s:%d", loc.FilePath, loc.LineNum)))
 	} else {
 		content.WriteString(m.styles.ModalLabelStyle.Render("Definition History:"))
 		content.WriteString("\n")
 		for i, def := range alias.Definitions {
-			isActive := i == len(alias.Definitions)-1
+			isActive := i == len(alias.Definitions)-1 && !alias.IsRemoved
 			marker := fmt.Sprintf("%d.", i+1)
 			valueStr := def.Value
+			if def.Removed {
+				valueStr = m.styles.RemovedBadgeStyle.Render("unalias")
+			}
+			if def.Excluded {
+				valueStr += " " + m.styles.ConditionalBadgeStyle.Render("excluded")
+			}
 			if isActive {
 				marker = m.styles.ModalActiveStyle.Render(marker)
 				valueStr = m.styles.ModalActiveStyle.Render(valueStr + " [ACTIVE]")
 			}
 			content.WriteString(fmt.Sprintf("  %s %s\n", marker, valueStr))
 			content.WriteString(fmt.Sprintf("     %s\n",
-				m.styles.MutedStyle.Render(fmt.Sprintf("%s:%d", def.Location.FilePath, def.Location.LineNum))))
+				m.styles.MutedStyle.Render(fmt.Sprintf("%s:%s", def.Location.FilePath, def.Location.LineRange()))))
+			if len(def.Conditions) > 0 {
+				content.WriteString(fmt.Sprintf("     %s\n",
+					m.styles.MutedStyle.Render("if "+model.ConditionText(def.Conditions))))
+			}
 		}
 	}
 
@@ -299,6 +539,125 @@ func (m Model) renderDetails() string {
 		box)
 }
 
+// describeShadow describes a command an alias hides: "builtin cd (high)"
+func describeShadow(name string, shadow model.Shadow) string {
+	switch {
+	case shadow.Path != "":
+		name = shadow.Path
+	case shadow.Location != nil:
+		name = fmt.Sprintf("%s() at %s:%s", name, shadow.Location.FilePath, shadow.Location.LineRange())
+	}
+	return fmt.Sprintf("%s %s (%s)", shadow.Kind, name, shadow.Severity)
+}
+
+// renderFunctionDetails renders the details modal for a function
+func (m Model) renderFunctionDetails() string {
+	fn := m.getCurrentFunction()
+	if fn == nil {
+		return "No function selected"
+	}
+
+	var content strings.Builder
+
+	// Title
+	content.WriteString(m.styles.ModalTitleStyle.Render("Function Details"))
+	content.WriteString("\n\n")
+
+	// Name
+	content.WriteString(m.styles.ModalLabelStyle.Render("Name: "))
+	content.WriteString(m.styles.ModalValueStyle.Render(fn.Name))
+	content.WriteString("\n\n")
+
+	// Body
+	content.WriteString(m.styles.ModalLabelStyle.Render("Body:"))
+	content.WriteString("\n")
+	content.WriteString(m.styles.ModalValueStyle.Render(fn.ActiveBody))
+	content.WriteString("\n\n")
+
+	// Definitions
+	if len(fn.Definitions) == 1 {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Defined in:"))
+		content.WriteString("\n")
+		loc := fn.ActiveLocation
+		content.WriteString("  " + m.styles.ModalValueStyle.Render(fmt.Sprintf("%s:%s", loc.FilePath, loc.LineRange())))
+		content.WriteString("\n\n")
+		content.WriteString(m.styles.HelpStyle.Render(fmt.Sprintf("Open: code -g %s:%d", loc.FilePath, loc.LineNum)))
+	} else {
+		content.WriteString(m.styles.ModalLabelStyle.Render("Definition History:"))
+		content.WriteString("\n")
+		for i, def := range fn.Definitions {
+			marker := fmt.Sprintf("%d.", i+1)
+			locStr := fmt.Sprintf("%s:%s", def.Location.FilePath, def.Location.LineRange())
+			if i == len(fn.Definitions)-1 {
+				marker = m.styles.ModalActiveStyle.Render(marker)
+				locStr = m.styles.ModalActiveStyle.Render(locStr + " [ACTIVE]")
+			}
+			content.WriteString(fmt.Sprintf("  %s %s\n", marker, locStr))
+		}
+	}
+
+	content.WriteString("\n")
+	content.WriteString(m.styles.HelpStyle.Render("[ESC to close]"))
+
+	box := m.styles.ModalBoxStyle.Render(content.String())
+
+	// Center the modal
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		box)
+}
+
+// renderDynamicDetails renders the details modal for a dynamic include
+func (m Model) renderDynamicDetails() string {
+	dyn := m.getCurrentDynamic()
+	if dyn == nil {
+		return "No dynamic include selected"
+	}
+
+	var content strings.Builder
+
+	// Title
+	content.WriteString(m.styles.ModalTitleStyle.Render("Dynamic Include Details"))
+	content.WriteString("\n\n")
+
+	content.WriteString(m.styles.ModalLabelStyle.Render("Tool: "))
+	content.WriteString(m.styles.ModalValueStyle.Render(dyn.Tool))
+	content.WriteString("\n")
+
+	content.WriteString(m.styles.ModalLabelStyle.Render("Command: "))
+	content.WriteString(m.styles.ModalValueStyle.Render(dyn.Command))
+	content.WriteString("\n")
+
+	// What the generated code may define
+	content.WriteString(m.styles.ModalLabelStyle.Render("Aliases: "))
+	switch {
+	case len(dyn.Aliases) > 0:
+		content.WriteString(m.styles.ModalValueStyle.Render(strings.Join(dyn.Aliases, ", ") + " (documented by the tool)"))
+	case dyn.Known:
+		content.WriteString(m.styles.ModalValueStyle.Render("none documented"))
+	default:
+		content.WriteString(m.styles.ModalValueStyle.Render("unknown tool, may define aliases"))
+	}
+	content.WriteString("\n\n")
+
+	content.WriteString(m.styles.ModalLabelStyle.Render("Defined in:"))
+	content.WriteString("\n")
+	loc := dyn.Location
+	content.WriteString("  " + m.styles.ModalValueStyle.Render(fmt.Sprintf("%s:%s", loc.FilePath, loc.LineRange())))
+	content.WriteString("\n\n")
+	content.WriteString(m.styles.HelpStyle.Render(fmt.Sprintf("Open: code -g %s:%d", loc.FilePath, loc.LineNum)))
+
+	content.WriteString("\n\n")
+	content.WriteString(m.styles.HelpStyle.Render("[ESC to close]"))
+
+	box := m.styles.ModalBoxStyle.Render(content.String())
+
+	// Center the modal
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		box)
+}
+
 // renderHelp renders the help modal
 func (m Model) renderHelp() string {
 	var content strings.Builder
@@ -316,7 +675,7 @@ func (m Model) renderHelp() string {
 		{"c", "Copy alias value to clipboard"},
 		{"n", "Copy alias name to clipboard"},
 		{"p", "Copy full alias definition"},
-		{"t", "Toggle view mode (All/By File/Overridden/Globals)"},
+		{"t", "Toggle view mode (All/By File/Overridden/Globals/Suffixes/Removed/Dead/Functions/Dynamic)"},
 		{"r", "Rescan configuration files"},
 		{"h or ?", "Show this help"},
 		{"q or Ctrl+C", "Quit"},